@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,13 +15,90 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
-	JWTSecret  string
 	ServerPort string
 	Env        string
+
+	// Key ring JWT: direktori kunci PEM, algoritma kunci yang dibuat otomatis (RS256 / EdDSA),
+	// dan kid kunci aktif (kosong = kunci terbaru)
+	JWTKeysDir   string
+	JWTAlgorithm string
+	JWTActiveKID string
+
+	// Umur access token (JWT) dan refresh token
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Passphrase untuk enkripsi secret TOTP di database
+	TwoFactorEncryptionKey string
+
+	// Login gagal berturut-turut sebelum akun dikunci, dan lama penguncian
+	LoginMaxFailures   int
+	LoginLockoutPeriod time.Duration
+
+	// Base URL publik API (untuk callback & link)
+	AppBaseURL string
+
+	// Email: "log" (development, tulis ke log / MailDir) atau "smtp"
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Link di email reset password (deep link app / halaman web), token ditambahkan sebagai ?token=
+	PasswordResetURL string
+
+	// Komisi default (persen) untuk produk tanpa consignment agreement
+	DefaultCommissionRate float64
+
+	// Payment gateway: "fake" (lokal) atau "gateway"
+	PaymentProvider      string
+	PaymentAPIURL        string
+	PaymentAPIKey        string
+	PaymentWebhookSecret string
+
+	// Penyimpanan upload: "local" (filesystem, disajikan di /uploads/) atau "s3" (S3 / MinIO)
+	StorageDriver  string
+	UploadDir      string
+	UploadBaseURL  string
+	UploadMaxBytes int
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PublicURL    string
+
+	// Pencarian full-text: "mysql" (index FULLTEXT) atau "bleve" (embedded, kosong = in-memory)
+	SearchDriver    string
+	SearchIndexPath string
+
+	// Lama hold produk untuk order yang belum dibayar, dan interval worker yang melepasnya
+	ReservationTTL           time.Duration
+	ReservationSweepInterval time.Duration
+
+	// Interval worker yang mengirim ulang refund gateway yang masih pending
+	RefundRetryInterval time.Duration
 }
 
 var AppConfig *Config
 
+// Nilai default secret untuk development; ditolak di environment lain
+const (
+	devTwoFactorEncryptionKey = "dev-two-factor-key"
+	devPaymentWebhookSecret   = "dev-webhook-secret"
+)
+
+// placeholderSecrets - nilai contoh dari .env.example yang juga ditolak di luar development
+var placeholderSecrets = map[string]bool{
+	devTwoFactorEncryptionKey:    true,
+	devPaymentWebhookSecret:      true,
+	"change-this-two-factor-key": true,
+	"change-this-webhook-secret": true,
+}
+
 // LoadConfig membaca .env file dan set config
 func LoadConfig() {
 	// Load .env file
@@ -34,16 +113,85 @@ func LoadConfig() {
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "sk8consign"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		Env:        getEnv("ENV", "development"),
+
+		JWTKeysDir:   getEnv("JWT_KEYS_DIR", "keys"),
+		JWTAlgorithm: getEnv("JWT_ALGORITHM", "RS256"),
+		JWTActiveKID: getEnv("JWT_ACTIVE_KID", ""),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		TwoFactorEncryptionKey: getEnv("TWO_FACTOR_ENCRYPTION_KEY", devTwoFactorEncryptionKey),
+
+		LoginMaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 10),
+		LoginLockoutPeriod: getEnvDuration("LOGIN_LOCKOUT_PERIOD", 30*time.Minute),
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:8080"),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "SK8 Consign <no-reply@sk8consign.local>"),
+		MailDir:      getEnv("MAIL_DIR", ""),
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		PasswordResetURL: getEnv("PASSWORD_RESET_URL", "sk8consign://reset-password"),
+
+		DefaultCommissionRate: getEnvFloat("DEFAULT_COMMISSION_RATE", 20),
+
+		PaymentProvider:      getEnv("PAYMENT_PROVIDER", "fake"),
+		PaymentAPIURL:        getEnv("PAYMENT_API_URL", ""),
+		PaymentAPIKey:        getEnv("PAYMENT_API_KEY", ""),
+		PaymentWebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", devPaymentWebhookSecret),
+
+		StorageDriver:  getEnv("STORAGE_DRIVER", "local"),
+		UploadDir:      getEnv("UPLOAD_DIR", "uploads"),
+		UploadBaseURL:  getEnv("UPLOAD_BASE_URL", ""),
+		UploadMaxBytes: getEnvInt("UPLOAD_MAX_BYTES", 15<<20),
+		S3Endpoint:     getEnv("S3_ENDPOINT", "http://localhost:9000"),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", "sk8consign"),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		S3PublicURL:    getEnv("S3_PUBLIC_URL", ""),
+
+		SearchDriver:    getEnv("SEARCH_DRIVER", "mysql"),
+		SearchIndexPath: getEnv("SEARCH_INDEX_PATH", ""),
+
+		ReservationTTL:           getEnvDuration("RESERVATION_TTL", 30*time.Minute),
+		ReservationSweepInterval: getEnvDuration("RESERVATION_SWEEP_INTERVAL", time.Minute),
+
+		RefundRetryInterval: getEnvDuration("REFUND_RETRY_INTERVAL", 5*time.Minute),
 	}
 
+	validateSecrets(AppConfig)
+
 	log.Println("✅ Configuration loaded")
 	log.Printf("   Database: %s@%s:%s/%s", AppConfig.DBUser, AppConfig.DBHost, AppConfig.DBPort, AppConfig.DBName)
 	log.Printf("   Environment: %s", AppConfig.Env)
 }
 
+// validateSecrets - di luar development, secret wajib diisi dengan nilai sendiri.
+// Webhook secret default membuat siapa pun bisa menandatangani webhook "paid" palsu.
+func validateSecrets(cfg *Config) {
+	if cfg.Env == "development" {
+		return
+	}
+
+	secrets := map[string]string{
+		"TWO_FACTOR_ENCRYPTION_KEY": cfg.TwoFactorEncryptionKey,
+		"PAYMENT_WEBHOOK_SECRET":    cfg.PaymentWebhookSecret,
+	}
+	for name, value := range secrets {
+		if value == "" || placeholderSecrets[value] {
+			log.Fatalf("❌ %s must be set to a unique secret when ENV=%s", name, cfg.Env)
+		}
+	}
+}
+
 // getEnv helper untuk ambil env dengan default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -52,3 +200,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt helper untuk ambil env integer dengan default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat helper untuk ambil env numerik dengan default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration helper untuk ambil env durasi (contoh: "30m", "2h") dengan default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
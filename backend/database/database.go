@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"sk8consign-backend/config"
+	"sk8consign-backend/ledger"
 	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -71,20 +73,65 @@ func AutoMigrate() {
 
 	modelsToMigrate := []interface{}{
 		&models.User{},
+		&models.RefreshToken{},
+		&models.UserToken{},
+		&models.LoginAttempt{},
+		&models.RecoveryCode{},
+		&models.TwoFactorPolicy{},
 		&models.Product{},
+		&models.ProductImage{},
 		&models.Cart{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.PaymentIntent{},
+		&models.Reservation{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.Refund{},
 		&models.Notification{},
+		&models.ConsignmentAgreement{},
+		&models.ConsignorPayout{},
+		&models.LedgerAccount{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.AuditLog{},
 	}
 
 	if err := DB.AutoMigrate(modelsToMigrate...); err != nil {
 		log.Fatal("❌ Migration failed:", err)
 	}
 
+	if err := ledger.SeedAccounts(DB); err != nil {
+		log.Fatal("❌ Failed to seed ledger accounts:", err)
+	}
+
+	if err := migrateLegacyRoles(); err != nil {
+		log.Fatal("❌ Failed to migrate user roles:", err)
+	}
+
 	log.Println("✅ Database migration completed")
 }
 
+// migrateLegacyRoles - role lama "user" dipecah: pemilik produk / perjanjian
+// titip jual menjadi consignor, sisanya buyer
+func migrateLegacyRoles() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Unscoped().
+			Where("role = ?", "user").
+			Where("id IN (?) OR id IN (?)",
+				tx.Model(&models.Product{}).Unscoped().Select("user_id"),
+				tx.Model(&models.ConsignmentAgreement{}).Select("consignor_id")).
+			Update("role", rbac.RoleConsignor).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Unscoped().
+			Where("role = ? OR role = ''", "user").
+			Update("role", rbac.RoleBuyer).Error
+	})
+}
+
 // Close - close database connection
 func Close() {
 	sqlDB, err := DB.DB()
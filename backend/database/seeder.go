@@ -3,9 +3,12 @@ package database
 import (
 	"log"
 	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
 	"sk8consign-backend/utils"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // SeedData - seed initial data untuk development
@@ -47,7 +50,7 @@ func seedUsers() {
 			Password: "admin123",
 			FullName: "Admin SK8 Consign",
 			Phone:    "081234567890",
-			Role:     "admin",
+			Role:     rbac.RoleAdmin,
 		},
 		{
 			Username: "user",
@@ -55,7 +58,23 @@ func seedUsers() {
 			Password: "user123",
 			FullName: "Regular User",
 			Phone:    "081234567891",
-			Role:     "user",
+			Role:     rbac.RoleConsignor, // punya produk titipan, juga bisa belanja
+		},
+		{
+			Username: "moderator",
+			Email:    "moderator@sk8consign.com",
+			Password: "moderator123",
+			FullName: "Moderator SK8 Consign",
+			Phone:    "081234567892",
+			Role:     rbac.RoleModerator,
+		},
+		{
+			Username: "finance",
+			Email:    "finance@sk8consign.com",
+			Password: "finance123",
+			FullName: "Finance SK8 Consign",
+			Phone:    "081234567893",
+			Role:     rbac.RoleFinance,
 		},
 	}
 
@@ -67,16 +86,18 @@ func seedUsers() {
 			continue
 		}
 
-		// Create user
+		// Create user (akun seed dianggap sudah terverifikasi)
+		verifiedAt := time.Now()
 		user := models.User{
-			ID:       uuid.New().String(),
-			Username: userData.Username,
-			Email:    userData.Email,
-			Password: hashedPassword,
-			FullName: userData.FullName,
-			Phone:    userData.Phone,
-			Role:     userData.Role,
-			IsActive: true,
+			ID:              uuid.New().String(),
+			Username:        userData.Username,
+			Email:           userData.Email,
+			Password:        hashedPassword,
+			FullName:        userData.FullName,
+			Phone:           userData.Phone,
+			Role:            userData.Role,
+			IsActive:        true,
+			EmailVerifiedAt: &verifiedAt,
 		}
 
 		if err := DB.Create(&user).Error; err != nil {
@@ -251,11 +272,28 @@ func seedNotifications() {
 func ClearData() {
 	log.Println("⚠️  Clearing all data...")
 
+	// Audit log menolak delete lewat hook, jadi hook dilewati khusus di sini
+	DB.Session(&gorm.Session{SkipHooks: true}).Unscoped().Where("1 = 1").Delete(&models.AuditLog{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.JournalLine{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.JournalEntry{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.Refund{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.ReturnItem{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.ReturnRequest{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.ConsignorPayout{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.ConsignmentAgreement{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.Notification{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.Reservation{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.PaymentIntent{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.OrderStatusHistory{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.OrderItem{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.Order{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.Cart{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.ProductImage{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.Product{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.RecoveryCode{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.LoginAttempt{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.UserToken{})
+	DB.Unscoped().Where("1 = 1").Delete(&models.RefreshToken{})
 	DB.Unscoped().Where("1 = 1").Delete(&models.User{})
 
 	log.Println("✅ All data cleared")
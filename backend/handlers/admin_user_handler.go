@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/rbac"
+	"sk8consign-backend/services"
+	"strconv"
+)
+
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListUsers handler - cari user (q, role, status) dengan pagination
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	filter := services.UserSearchFilter{
+		Query:  r.URL.Query().Get("q"),
+		Role:   r.URL.Query().Get("role"),
+		Status: r.URL.Query().Get("status"),
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	users, total, err := services.SearchUsers(filter, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var userResponses []interface{}
+	for _, user := range users {
+		userResponses = append(userResponses, user.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Users retrieved successfully",
+		"data": map[string]interface{}{
+			"users": userResponses,
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// GetUserDetail handler - detail user beserta jumlah produk & order
+func GetUserDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	detail, err := services.GetAdminUserDetail(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "User retrieved successfully",
+		"data":    detail,
+	})
+}
+
+// GetUserProductsAdmin handler - produk milik satu user (semua status)
+func GetUserProductsAdmin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	products, total, err := services.GetUserProducts(userID, status, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get products",
+		})
+		return
+	}
+
+	var productResponses []interface{}
+	for _, product := range products {
+		productResponses = append(productResponses, product.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Products retrieved successfully",
+		"data": map[string]interface{}{
+			"products": productResponses,
+			"total":    total,
+			"page":     page,
+			"limit":    limit,
+		},
+	})
+}
+
+// GetUserOrdersAdmin handler - order milik satu user
+func GetUserOrdersAdmin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	orders, total, err := services.GetUserOrders(userID, status, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get orders",
+		})
+		return
+	}
+
+	var orderResponses []interface{}
+	for _, order := range orders {
+		orderResponses = append(orderResponses, order.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Orders retrieved successfully",
+		"data": map[string]interface{}{
+			"orders": orderResponses,
+			"total":  total,
+			"page":   page,
+			"limit":  limit,
+		},
+	})
+}
+
+// SuspendUser handler - tangguhkan akun dengan alasan
+func SuspendUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	var req SuspendUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	user, err := services.SuspendUser(auditActor(r), userID, req.Reason)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "User suspended successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// ReactivateUser handler - aktifkan kembali akun yang ditangguhkan
+func ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	user, err := services.ReactivateUser(auditActor(r), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "User reactivated successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// ForcePasswordReset handler - paksa user memilih password baru lewat email
+func ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	user, err := services.ForcePasswordReset(auditActor(r), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Password reset forced, the user has been signed out and emailed a reset link",
+		"data":    user.ToResponse(),
+	})
+}
+
+// UnlockUser handler - admin membuka kunci akun yang terkunci karena login gagal
+func UnlockUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	user, err := services.UnlockAccount(r.Context(), auditActor(r), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("🔓 Account unlocked: %s by %s", user.Username, middleware.Username(r.Context()))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Account unlocked successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// GetRoles handler - daftar role beserta permission-nya
+func GetRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	roles := make([]map[string]interface{}, 0, len(rbac.Roles()))
+	for _, role := range rbac.Roles() {
+		roles = append(roles, map[string]interface{}{
+			"role":        role,
+			"permissions": rbac.Permissions(role),
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Roles retrieved successfully",
+		"data":    roles,
+	})
+}
+
+// AssignUserRole handler - admin mengganti role user
+func AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := r.URL.Query().Get("id")
+	if userID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	user, err := services.AssignRole(auditActor(r), userID, req.Role)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Role updated successfully",
+		"data":    user.ToResponse(),
+	})
+}
+
+// auditActor - identitas admin + asal request untuk audit log
+func auditActor(r *http.Request) services.AuditActor {
+	return services.AuditActor{
+		UserID:    middleware.UserID(r.Context()),
+		Role:      middleware.Role(r.Context()),
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
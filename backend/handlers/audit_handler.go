@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sk8consign-backend/services"
+	"strconv"
+	"time"
+)
+
+// ListAuditLogs handler (admin) - query audit log dengan filter actor, aksi, target & tanggal
+func ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := services.AuditFilter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+
+	// from / to opsional, format YYYY-MM-DD (to inklusif sampai akhir hari)
+	if value := query.Get("from"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "from must be in YYYY-MM-DD format",
+			})
+			return
+		}
+		filter.From = &date
+	}
+	if value := query.Get("to"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "to must be in YYYY-MM-DD format",
+			})
+			return
+		}
+		end := date.Add(24 * time.Hour)
+		filter.To = &end
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	logs, total, err := services.SearchAuditLogs(filter, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Audit logs retrieved successfully",
+		"data": map[string]interface{}{
+			"logs":  logs,
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
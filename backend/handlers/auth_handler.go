@@ -2,9 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"sk8consign-backend/middleware"
 	"sk8consign-backend/services"
+	"strconv"
 )
 
 type AuthHandler struct {
@@ -31,11 +36,49 @@ type RegisterRequest struct {
 	Phone    string `json:"phone"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	AllDevices   bool   `json:"all_devices"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // kode TOTP 6 digit atau recovery code
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Token   string      `json:"token,omitempty"`
+	Success      bool        `json:"success"`
+	Message      string      `json:"message"`
+	Data         interface{} `json:"data,omitempty"`
+	Token        string      `json:"token,omitempty"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	ExpiresIn    int64       `json:"expires_in,omitempty"`
 }
 
 // Login handler
@@ -63,25 +106,54 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call service
-	user, token, err := h.authService.Login(req.Username, req.Password)
+	user, tokens, err := h.authService.Login(req.Username, req.Password, r.UserAgent(), clientIP(r))
+
+	// Password benar, tapi perlu langkah kedua (kode 2FA)
+	var challenge *services.TwoFactorRequiredError
+	if errors.As(err, &challenge) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{
+			Success: true,
+			Message: "Masukkan kode autentikasi dua langkah",
+			Data: map[string]interface{}{
+				"two_factor_required": true,
+				"challenge_token":     challenge.ChallengeToken,
+				"expires_in":          challenge.ExpiresIn,
+			},
+		})
+		log.Printf("🔐 Login 2FA challenge: %s", req.Username)
+		return
+	}
+
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		status := http.StatusUnauthorized
+
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			status = http.StatusTooManyRequests
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		}
+
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(Response{
 			Success: false,
 			Message: err.Error(),
 		})
-		log.Printf("❌ Login failed: %s - %v", req.Username, err)
+		log.Printf("❌ Login failed: %s from %s - %v", req.Username, clientIP(r), err)
 		return
 	}
 
 	// Success response
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(Response{
-		Success: true,
-		Message: "Login berhasil",
-		Token:   token,
+		Success:      true,
+		Message:      "Login berhasil",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
 		Data: map[string]interface{}{
-			"user": user.ToResponse(),
+			"user":                      user.ToResponse(),
+			"two_factor_setup_required": tokens.TwoFactorSetupRequired,
 		},
 	})
 
@@ -134,6 +206,264 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	log.Printf("✅ Register success: %s", req.Username)
 }
 
+// Refresh handler - tukar refresh token dengan access token + refresh token baru
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	user, tokens, err := services.RefreshTokens(req.RefreshToken, r.UserAgent(), clientIP(r))
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			log.Printf("⚠️  Refresh token reuse from %s", clientIP(r))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success:      true,
+		Message:      "Token refreshed",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		Data: map[string]interface{}{
+			"user": user.ToResponse(),
+		},
+	})
+}
+
+// Logout handler - cabut refresh token (atau semua sesi dengan all_devices)
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.Logout(req.RefreshToken, req.AllDevices); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Logout berhasil",
+	})
+}
+
+// RequestEmailVerification handler - kirim ulang email verifikasi untuk user yang login
+func (h *AuthHandler) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	if err := services.RequestEmailVerification(userID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
+
+// VerifyEmail handler - konfirmasi email dengan token (GET dari link email, atau POST dari app)
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if r.Method == http.MethodPost {
+		var req VerifyEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(Response{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+		token = req.Token
+	}
+
+	if err := services.ConfirmEmailVerification(token); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Email verified successfully",
+	})
+}
+
+// RequestPasswordReset handler - kirim link reset password ke email
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.RequestPasswordReset(req.Email); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Pesan sama untuk email terdaftar maupun tidak
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "If the email is registered, a reset link has been sent",
+	})
+}
+
+// ConfirmPasswordReset handler - set password baru dengan token reset
+func (h *AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.ResetPassword(req.Token, req.NewPassword, r.UserAgent(), clientIP(r)); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Password has been reset, please login",
+	})
+}
+
+// clientIP - alamat IP koneksi (tanpa port). X-Forwarded-For tidak dipercaya.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // Health check handler
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
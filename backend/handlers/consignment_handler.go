@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/models"
+	"sk8consign-backend/services"
+	"strconv"
+	"time"
+)
+
+// CreateConsignmentRequest - request structure
+type CreateConsignmentRequest struct {
+	ProductID       string                  `json:"product_id"`
+	ConsignorID     string                  `json:"consignor_id"`
+	CommissionType  string                  `json:"commission_type"`
+	CommissionRate  float64                 `json:"commission_rate"`
+	CommissionTiers []models.CommissionTier `json:"commission_tiers"`
+	FloorPrice      float64                 `json:"floor_price"`
+	ExpiresAt       *time.Time              `json:"expires_at"`
+}
+
+// CreateConsignmentAgreement handler (admin)
+func CreateConsignmentAgreement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	var req CreateConsignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if req.ProductID == "" || req.ConsignorID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID and consignor ID are required",
+		})
+		return
+	}
+
+	agreement, err := services.CreateConsignmentAgreement(
+		req.ProductID,
+		req.ConsignorID,
+		req.CommissionType,
+		req.CommissionRate,
+		req.CommissionTiers,
+		req.FloorPrice,
+		req.ExpiresAt,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Consignment agreement created successfully",
+		"data":    agreement.ToResponse(),
+	})
+}
+
+// GetMyConsignments handler - perjanjian milik consignor yang login
+func GetMyConsignments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	agreements, err := services.GetConsignorAgreements(userID, r.URL.Query().Get("status"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get consignment agreements",
+		})
+		return
+	}
+
+	agreementResponses := make([]interface{}, 0, len(agreements))
+	for _, agreement := range agreements {
+		agreementResponses = append(agreementResponses, agreement.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Consignment agreements retrieved successfully",
+		"data":    agreementResponses,
+	})
+}
+
+// GetSellerEarnings handler - ringkasan earned vs pending
+func GetSellerEarnings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	earnings, err := services.GetSellerEarnings(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get earnings",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Earnings retrieved successfully",
+		"data":    earnings,
+	})
+}
+
+// GetSellerPayouts handler - list payout ledger milik seller
+func GetSellerPayouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	payouts, total, err := services.GetSellerPayouts(userID, status, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get payouts",
+		})
+		return
+	}
+
+	var payoutResponses []interface{}
+	for _, payout := range payouts {
+		payoutResponses = append(payoutResponses, payout.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Payouts retrieved successfully",
+		"data": map[string]interface{}{
+			"payouts": payoutResponses,
+			"total":   total,
+			"page":    page,
+			"limit":   limit,
+		},
+	})
+}
+
+// MarkPayoutPaid handler (admin) - tandai payout sudah ditransfer
+func MarkPayoutPaid(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	payoutID := r.URL.Query().Get("id")
+	if payoutID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Payout ID is required",
+		})
+		return
+	}
+
+	payout, err := services.MarkPayoutPaid(payoutID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Payout marked as paid",
+		"data":    payout.ToResponse(),
+	})
+}
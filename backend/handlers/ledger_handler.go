@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sk8consign-backend/services"
+	"time"
+)
+
+// GetTrialBalance handler (admin/finance) - trial balance dari journal ledger
+func GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	// as_of opsional, format YYYY-MM-DD (inklusif sampai akhir hari)
+	asOf := time.Now()
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "as_of must be in YYYY-MM-DD format",
+			})
+			return
+		}
+		asOf = date.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	report, err := services.GetTrialBalance(asOf)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to build trial balance",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Trial balance retrieved successfully",
+		"data":    report,
+	})
+}
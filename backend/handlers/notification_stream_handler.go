@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/services"
+	"time"
+)
+
+const (
+	notificationHeartbeatInterval = 25 * time.Second
+	notificationReplayLimit       = 100
+)
+
+// StreamNotifications handler - Server-Sent Events untuk notifikasi baru dan unread count.
+// Client yang reconnect mengirim header Last-Event-ID (atau query last_event_id)
+// untuk menerima notifikasi yang terlewat.
+func StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	identity, _ := middleware.IdentityFrom(r.Context())
+	userID := identity.UserID
+	if userID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Streaming not supported",
+		})
+		return
+	}
+
+	// Subscribe sebelum replay supaya tidak ada notifikasi yang hilang di antaranya
+	events, unsubscribe := services.NotificationStream.Subscribe(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", 5000)
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	replayed := make(map[string]bool)
+	if lastEventID != "" {
+		missed, err := services.GetNotificationsSince(userID, lastEventID, notificationReplayLimit)
+		if err == nil {
+			for _, notif := range missed {
+				writeServerSentEvent(w, notif.ID, services.StreamEventNotification, notif.ToResponse())
+				replayed[notif.ID] = true
+			}
+		}
+	}
+
+	if count, err := services.GetUnreadCount(userID); err == nil {
+		writeServerSentEvent(w, "", services.StreamEventUnreadCount, map[string]interface{}{
+			"unread_count": count,
+		})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(notificationHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	// Stream tidak boleh hidup lebih lama dari access token-nya
+	var expired <-chan time.Time
+	if !identity.ExpiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(identity.ExpiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired:
+			endNotificationStream(w, flusher, "token_expired")
+			return
+		case <-heartbeat.C:
+			// Logout semua device, ganti password / role, atau suspend menutup stream
+			if err := services.CheckSessionActive(identity.UserID, identity.Role, identity.IssuedAt); err != nil {
+				endNotificationStream(w, flusher, "session_revoked")
+				return
+			}
+			fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().Unix())
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.ID != "" && replayed[event.ID] {
+				continue
+			}
+			writeServerSentEvent(w, event.ID, event.Type, event.Data)
+			flusher.Flush()
+		}
+	}
+}
+
+// endNotificationStream - beri tahu client alasan stream ditutup supaya refresh
+// token dulu (atau login ulang) sebelum reconnect
+func endNotificationStream(w http.ResponseWriter, flusher http.Flusher, reason string) {
+	writeServerSentEvent(w, "", services.StreamEventSessionEnded, map[string]interface{}{
+		"reason": reason,
+	})
+	flusher.Flush()
+}
+
+// writeServerSentEvent - tulis satu event dalam format SSE
+func writeServerSentEvent(w http.ResponseWriter, id, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
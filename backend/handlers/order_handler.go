@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sk8consign-backend/middleware"
 	"sk8consign-backend/services"
 	"strconv"
 )
@@ -15,10 +17,7 @@ type CreateOrderRequest struct {
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
-}
-
-type UpdatePaymentStatusRequest struct {
-	PaymentStatus string `json:"payment_status"`
+	Note   string `json:"note"`
 }
 
 func CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +32,7 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -64,7 +63,12 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 	order, err := services.CreateOrder(userID, req.PaymentMethod, req.ShippingAddress, req.Notes)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrProductConflict) {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
 			"message": err.Error(),
@@ -92,7 +96,7 @@ func GetUserOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -155,7 +159,7 @@ func GetOrderDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -205,7 +209,7 @@ func UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -235,7 +239,7 @@ func UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := services.UpdateOrderStatus(orderID, userID, req.Status)
+	err := services.UpdateOrderStatus(r.Context(), auditActor(r), orderID, req.Status, req.Note)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -251,65 +255,3 @@ func UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 		"message": "Order status updated successfully",
 	})
 }
-
-func UpdatePaymentStatus(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if r.Method != http.MethodPut {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Method not allowed",
-		})
-		return
-	}
-
-	userID := r.Header.Get("X-User-ID")
-	if userID == "" {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Unauthorized",
-		})
-		return
-	}
-
-	orderID := r.URL.Query().Get("id")
-	if orderID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Order ID is required",
-		})
-		return
-	}
-
-	var req UpdatePaymentStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Invalid request body",
-		})
-		return
-	}
-
-	err := services.UpdatePaymentStatus(orderID, userID, req.PaymentStatus)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": err.Error(),
-		})
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "Payment status updated successfully",
-	})
-}
-
-
-
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/payment"
+	"sk8consign-backend/services"
+)
+
+// maxWebhookBodySize - batas ukuran body webhook (1 MB)
+const maxWebhookBodySize = 1 << 20
+
+type CreatePaymentIntentRequest struct {
+	Method string `json:"method"`
+}
+
+type FakePaymentRequest struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+// CreatePaymentIntent handler - buat instruksi pembayaran (redirect URL / VA) untuk order
+func CreatePaymentIntent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	orderID := r.URL.Query().Get("order_id")
+	if orderID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Order ID is required",
+		})
+		return
+	}
+
+	// Body opsional
+	var req CreatePaymentIntentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	intent, err := services.CreatePaymentIntent(r.Context(), orderID, userID, req.Method)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Payment intent created successfully",
+		"data":    intent.ToResponse(),
+	})
+}
+
+// PaymentWebhook handler - notifikasi dari payment gateway (tanpa JWT, diverifikasi lewat HMAC)
+func PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.HandlePaymentWebhook(r.Context(), r.Header, body); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			status = http.StatusUnauthorized
+		}
+
+		log.Printf("❌ Payment webhook rejected: %v", err)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Webhook processed",
+	})
+}
+
+// SimulateFakePayment handler - development only, memicu webhook fake provider
+func SimulateFakePayment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	var req FakePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reference == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Payment reference is required",
+		})
+		return
+	}
+
+	if err := services.SimulateFakePayment(r.Context(), req.Reference, req.Status); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Fake payment webhook delivered",
+	})
+}
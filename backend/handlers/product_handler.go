@@ -2,20 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/rbac"
 	"sk8consign-backend/services"
 	"strconv"
 )
 
 // SearchProductsRequest - request structure
 type SearchProductsRequest struct {
-	Query    string  `json:"query"`
-	Category string  `json:"category"`
-	MinPrice float64 `json:"min_price"`
-	MaxPrice float64 `json:"max_price"`
-	Status   string  `json:"status"`
-	Page     int     `json:"page"`
-	Limit    int     `json:"limit"`
+	Query        string   `json:"query"`
+	Category     string   `json:"category"`
+	Conditions   []string `json:"conditions"`    // multi-select: new, like_new, good, fair
+	SellerIDs    []string `json:"seller_ids"`    // multi-select
+	Brands       []string `json:"brands"`        // multi-select
+	Sizes        []string `json:"sizes"`         // multi-select
+	PriceBuckets []string `json:"price_buckets"` // multi-select, key dari facet price
+	MinPrice     float64  `json:"min_price"`
+	MaxPrice     float64  `json:"max_price"`
+	Status       string   `json:"status"`
+	Sort         string   `json:"sort"` // relevance, newest, price_asc, price_desc, most_viewed
+	Page         int      `json:"page"`
+	Limit        int      `json:"limit"`
 }
 
 // CreateProductRequest - request structure
@@ -25,6 +34,8 @@ type CreateProductRequest struct {
 	Price       float64 `json:"price"`
 	Category    string  `json:"category"`
 	Condition   string  `json:"condition"`
+	Brand       string  `json:"brand"`
+	Size        string  `json:"size"`
 	ImageURL    string  `json:"image_url"`
 }
 
@@ -35,6 +46,8 @@ type UpdateProductRequest struct {
 	Price       float64 `json:"price"`
 	Category    string  `json:"category"`
 	Condition   string  `json:"condition"`
+	Brand       string  `json:"brand"`
+	Size        string  `json:"size"`
 	Status      string  `json:"status"`
 	ImageURL    string  `json:"image_url"`
 }
@@ -74,16 +87,30 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	offset := (req.Page - 1) * req.Limit
 
 	// Search products
-	products, total, err := services.SearchProducts(
-		req.Query,
-		req.Category,
-		req.MinPrice,
-		req.MaxPrice,
-		req.Status,
-		req.Limit,
-		offset,
-	)
+	result, err := services.SearchProducts(r.Context(), services.ProductSearchParams{
+		Query:        req.Query,
+		Category:     req.Category,
+		Conditions:   req.Conditions,
+		SellerIDs:    req.SellerIDs,
+		Brands:       req.Brands,
+		Sizes:        req.Sizes,
+		PriceBuckets: req.PriceBuckets,
+		MinPrice:     req.MinPrice,
+		MaxPrice:     req.MaxPrice,
+		Status:       req.Status,
+		Sort:         req.Sort,
+		Limit:        req.Limit,
+		Offset:       offset,
+	})
 
+	if errors.Is(err, services.ErrInvalidProductSort) || errors.Is(err, services.ErrInvalidPriceBucket) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -96,7 +123,7 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 
 	// Convert to response
 	var productResponses []interface{}
-	for _, product := range products {
+	for _, product := range result.Products {
 		productResponses = append(productResponses, product.ToResponse())
 	}
 
@@ -107,9 +134,11 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 		"message": "Products retrieved successfully",
 		"data": map[string]interface{}{
 			"products": productResponses,
-			"total":    total,
+			"total":    result.Total,
 			"page":     req.Page,
 			"limit":    req.Limit,
+			"sort":     result.Sort,
+			"facets":   result.Facets,
 		},
 	})
 }
@@ -171,15 +200,18 @@ func GetUserProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from query
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "User ID is required",
-		})
-		return
+	// Produk milik user yang login; staff boleh melihat produk user lain lewat ?user_id=
+	userID := middleware.UserID(r.Context())
+	if target := r.URL.Query().Get("user_id"); target != "" && target != userID {
+		if !rbac.Can(middleware.Role(r.Context()), rbac.PermUserRead) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "You can only list your own products",
+			})
+			return
+		}
+		userID = target
 	}
 
 	// Get filters
@@ -242,7 +274,7 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user ID from context (will be set by auth middleware)
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -275,12 +307,14 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Create product
 	product, err := services.CreateProduct(
-		userID,
+		auditActor(r),
 		req.Name,
 		req.Description,
 		req.Price,
 		req.Category,
 		req.Condition,
+		req.Brand,
+		req.Size,
 		req.ImageURL,
 	)
 
@@ -294,11 +328,16 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	message := "Product created successfully"
+	if product.Status == services.ProductStatusPendingReview {
+		message = "Product submitted for review"
+	}
+
 	// Success response
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "Product created successfully",
+		"message": message,
 		"data":    product.ToResponse(),
 	})
 }
@@ -338,7 +377,7 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -378,13 +417,15 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product, err := services.UpdateProduct(
+		auditActor(r),
 		productID,
-		userID,
 		req.Name,
 		req.Description,
 		req.Price,
 		req.Category,
 		req.Condition,
+		req.Brand,
+		req.Size,
 		req.Status,
 		req.ImageURL,
 	)
@@ -418,7 +459,7 @@ func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Header.Get("X-User-ID")
+	userID := middleware.UserID(r.Context())
 	if userID == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -438,7 +479,7 @@ func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := services.DeleteProduct(productID, userID)
+	err := services.DeleteProduct(auditActor(r), productID)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
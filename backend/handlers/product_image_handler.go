@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sk8consign-backend/services"
+)
+
+// ReorderProductImagesRequest - urutan baru galeri (semua id foto produk)
+type ReorderProductImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
+}
+
+// UploadProductImage handler - upload satu foto (multipart field "image") ke galeri produk
+func UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	productID := r.URL.Query().Get("product_id")
+	if productID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID is required",
+		})
+		return
+	}
+
+	// Batasi body: satu file + sedikit ruang untuk header multipart
+	maxBytes := services.MaxImageBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": services.ErrImageTooLarge.Error(),
+			})
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid multipart form",
+		})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Image file is required (field \"image\")",
+		})
+		return
+	}
+	defer file.Close()
+
+	image, err := services.AddProductImage(r.Context(), auditActor(r), productID, file)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, services.ErrImageTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, services.ErrUnsupportedImageType):
+			status = http.StatusUnsupportedMediaType
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Image uploaded successfully",
+		"data":    image.ToResponse(),
+	})
+}
+
+// DeleteProductImage handler - hapus satu foto dari galeri produk
+func DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	productID := r.URL.Query().Get("product_id")
+	imageID := r.URL.Query().Get("id")
+	if productID == "" || imageID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID and image ID are required",
+		})
+		return
+	}
+
+	if err := services.DeleteProductImage(auditActor(r), productID, imageID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Image deleted successfully",
+	})
+}
+
+// SetPrimaryProductImage handler - jadikan foto sebagai foto utama produk
+func SetPrimaryProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	productID := r.URL.Query().Get("product_id")
+	imageID := r.URL.Query().Get("id")
+	if productID == "" || imageID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID and image ID are required",
+		})
+		return
+	}
+
+	if err := services.SetPrimaryProductImage(auditActor(r), productID, imageID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Primary image updated successfully",
+	})
+}
+
+// ReorderProductImages handler - atur ulang urutan galeri produk
+func ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	productID := r.URL.Query().Get("product_id")
+	if productID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID is required",
+		})
+		return
+	}
+
+	var req ReorderProductImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.ReorderProductImages(auditActor(r), productID, req.ImageIDs); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Images reordered successfully",
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sk8consign-backend/services"
+	"strconv"
+)
+
+// ReviewProductRequest - keputusan reviewer atas listing consignor
+type ReviewProductRequest struct {
+	Decision string `json:"decision"` // approve, reject, request_changes
+	Note     string `json:"note"`     // wajib untuk reject / request_changes
+}
+
+// GetProductReviewQueue handler (admin/moderator) - listing yang menunggu review
+func GetProductReviewQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	products, total, err := services.GetProductReviewQueue(limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get review queue",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var productResponses []interface{}
+	for _, product := range products {
+		productResponses = append(productResponses, product.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Review queue retrieved successfully",
+		"data": map[string]interface{}{
+			"products": productResponses,
+			"total":    total,
+			"page":     page,
+			"limit":    limit,
+		},
+	})
+}
+
+// ReviewProduct handler (admin/moderator) - approve, reject, atau minta perubahan listing
+func ReviewProduct(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	productID := r.URL.Query().Get("id")
+	if productID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Product ID is required",
+		})
+		return
+	}
+
+	var req ReviewProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	product, err := services.ReviewProduct(auditActor(r), productID, req.Decision, req.Note)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Product reviewed successfully",
+		"data":    product.ToResponse(),
+	})
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/models"
+	"sk8consign-backend/services"
+	"strconv"
+)
+
+type RefundItemRequest struct {
+	OrderItemID string  `json:"order_item_id"`
+	Amount      float64 `json:"amount"` // 0 = seluruh sisa subtotal item
+}
+
+type CreateReturnRequest struct {
+	OrderID   string              `json:"order_id"`
+	Reason    string              `json:"reason"`
+	PhotoURLs []string            `json:"photo_urls"`
+	Items     []RefundItemRequest `json:"items"`
+}
+
+type ReviewReturnRequest struct {
+	Action string `json:"action"` // approve, reject
+	Note   string `json:"note"`
+}
+
+type RefundOrderRequest struct {
+	Items  []RefundItemRequest `json:"items"` // kosong = refund seluruh order
+	Reason string              `json:"reason"`
+}
+
+func toRefundItemInputs(items []RefundItemRequest) []services.RefundItemInput {
+	inputs := make([]services.RefundItemInput, len(items))
+	for i, item := range items {
+		inputs[i] = services.RefundItemInput{OrderItemID: item.OrderItemID, Amount: item.Amount}
+	}
+	return inputs
+}
+
+// CreateReturn handler - buyer mengajukan retur dengan alasan dan foto
+func CreateReturn(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	var req CreateReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if req.OrderID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Order ID is required",
+		})
+		return
+	}
+
+	returnRequest, err := services.CreateReturnRequest(req.OrderID, userID, req.Reason, req.PhotoURLs, toRefundItemInputs(req.Items))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Return request submitted successfully",
+		"data":    returnRequest.ToResponse(),
+	})
+}
+
+// GetMyReturns handler - return request milik buyer
+func GetMyReturns(w http.ResponseWriter, r *http.Request) {
+	listReturns(w, r, func(userID, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+		return services.GetBuyerReturnRequests(userID, status, limit, offset)
+	})
+}
+
+// GetSellerReturns handler - return request untuk produk milik seller
+func GetSellerReturns(w http.ResponseWriter, r *http.Request) {
+	listReturns(w, r, func(userID, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+		return services.GetSellerReturnRequests(userID, status, limit, offset)
+	})
+}
+
+// GetAllReturns handler - semua return request (admin)
+func GetAllReturns(w http.ResponseWriter, r *http.Request) {
+	listReturns(w, r, func(userID, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+		return services.GetAllReturnRequests(status, limit, offset)
+	})
+}
+
+func listReturns(w http.ResponseWriter, r *http.Request, list func(userID, status string, limit, offset int) ([]models.ReturnRequest, int64, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit == 0 {
+		limit = 20
+	}
+	if page == 0 {
+		page = 1
+	}
+
+	offset := (page - 1) * limit
+
+	returnRequests, total, err := list(userID, status, limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to get return requests",
+		})
+		return
+	}
+
+	var returnResponses []interface{}
+	for _, returnRequest := range returnRequests {
+		returnResponses = append(returnResponses, returnRequest.ToResponse())
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Return requests retrieved successfully",
+		"data": map[string]interface{}{
+			"returns": returnResponses,
+			"total":   total,
+			"page":    page,
+			"limit":   limit,
+		},
+	})
+}
+
+// ReviewReturn handler - seller pemilik item / admin menyetujui atau menolak retur
+func ReviewReturn(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	returnID := r.URL.Query().Get("id")
+	if returnID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Return ID is required",
+		})
+		return
+	}
+
+	var req ReviewReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if req.Action != "approve" && req.Action != "reject" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Action must be approve or reject",
+		})
+		return
+	}
+
+	role := middleware.Role(r.Context())
+	returnRequest, err := services.ReviewReturnRequest(r.Context(), returnID, userID, role, req.Action == "approve", req.Note)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Return request " + returnRequest.Status,
+		"data":    returnRequest.ToResponse(),
+	})
+}
+
+// RefundOrder handler - admin me-refund item order secara partial / penuh
+func RefundOrder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Order ID is required",
+		})
+		return
+	}
+
+	var req RefundOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	refunds, err := services.RefundOrder(r.Context(), orderID, userID, toRefundItemInputs(req.Items), req.Reason)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Refund issued successfully",
+		"data":    refunds,
+	})
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sk8consign-backend/middleware"
+	"sk8consign-backend/services"
+	"strconv"
+)
+
+type TwoFactorPolicyRequest struct {
+	Role     string `json:"role"`
+	Required bool   `json:"required"`
+}
+
+// LoginTwoFactor handler - langkah kedua login dengan challenge token + kode 2FA
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	user, tokens, err := services.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, r.UserAgent(), clientIP(r))
+	if err != nil {
+		status := http.StatusUnauthorized
+
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			status = http.StatusTooManyRequests
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		log.Printf("❌ 2FA login failed from %s - %v", clientIP(r), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success:      true,
+		Message:      "Login berhasil",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		Data: map[string]interface{}{
+			"user": user.ToResponse(),
+		},
+	})
+
+	log.Printf("✅ Login success (2FA): %s (%s)", user.Username, user.Role)
+}
+
+// EnrollTwoFactor handler - buat secret TOTP baru untuk di-scan authenticator app
+func (h *AuthHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	enrollment, err := services.EnrollTwoFactor(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Scan the QR code with your authenticator app, then verify with a code",
+		Data:    enrollment,
+	})
+}
+
+// VerifyTwoFactor handler - aktifkan 2FA dengan kode pertama, kembalikan recovery codes
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	codes, err := services.VerifyTwoFactorEnrollment(auditActor(r), req.Code)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Two-factor authentication enabled. Save these recovery codes, they will not be shown again",
+		Data: map[string]interface{}{
+			"recovery_codes": codes,
+		},
+	})
+
+	log.Printf("🔐 2FA enabled: %s", middleware.Username(r.Context()))
+}
+
+// DisableTwoFactor handler - matikan 2FA (butuh password + kode)
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+		return
+	}
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	var req TwoFactorDisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.DisableTwoFactor(auditActor(r), req.Password, req.Code); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+
+	log.Printf("🔓 2FA disabled: %s", middleware.Username(r.Context()))
+}
+
+// RecoveryCodes handler - GET: sisa recovery code, POST: buat ulang (butuh kode TOTP)
+func (h *AuthHandler) RecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := middleware.UserID(r.Context())
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		remaining, err := services.GetRecoveryCodeCount(userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(Response{
+				Success: false,
+				Message: "Failed to get recovery codes",
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{
+			Success: true,
+			Message: "Recovery codes retrieved",
+			Data: map[string]interface{}{
+				"remaining": remaining,
+			},
+		})
+
+	case http.MethodPost:
+		var req TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(Response{
+				Success: false,
+				Message: "Invalid request body",
+			})
+			return
+		}
+
+		codes, err := services.RegenerateRecoveryCodes(userID, req.Code)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(Response{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Response{
+			Success: true,
+			Message: "New recovery codes generated, old codes no longer work",
+			Data: map[string]interface{}{
+				"recovery_codes": codes,
+			},
+		})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Message: "Method not allowed",
+		})
+	}
+}
+
+// TwoFactorPolicy handler - admin melihat (GET) / mengubah (PUT) kewajiban 2FA per role
+func TwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		policies, err := services.GetTwoFactorPolicies()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Failed to get 2FA policies",
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "2FA policies retrieved",
+			"data":    policies,
+		})
+
+	case http.MethodPut:
+		var req TwoFactorPolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Invalid request body",
+			})
+			return
+		}
+
+		policy, err := services.SetTwoFactorPolicy(auditActor(r), req.Role, req.Required)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "2FA policy updated",
+			"data":    policy,
+		})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"log"
 	"net/http"
+	"sk8consign-backend/middleware"
 	"sk8consign-backend/services"
+	"time"
 )
 
-// GetProfileRequest - request structure
+// GetProfileRequest - request structure. UserID opsional (client lama);
+// jika diisi harus sama dengan user yang login.
 type GetProfileRequest struct {
 	UserID string `json:"user_id"`
 }
@@ -19,6 +24,17 @@ type UpdateProfileRequest struct {
 	Email    string `json:"email"`
 }
 
+// DeleteAccountRequest - langkah pertama hapus akun
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"` // kode 2FA, wajib jika 2FA aktif
+}
+
+// ConfirmDeleteAccountRequest - kode konfirmasi dari email
+type ConfirmDeleteAccountRequest struct {
+	Token string `json:"token"`
+}
+
 // ChangePasswordRequest - request structure
 type ChangePasswordRequest struct {
 	UserID      string `json:"user_id"`
@@ -30,7 +46,7 @@ type ChangePasswordRequest struct {
 func GetProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodPost {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -39,29 +55,31 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request
+	// Body opsional: client lama masih mengirim user_id
 	var req GetProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"message": "Invalid request body",
-		})
-		return
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Invalid request body",
+			})
+			return
+		}
 	}
 
-	// Validate
-	if req.UserID == "" {
-		w.WriteHeader(http.StatusBadRequest)
+	userID := middleware.UserID(r.Context())
+	if req.UserID != "" && req.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"message": "User ID is required",
+			"message": "You can only access your own profile",
 		})
 		return
 	}
 
 	// Get profile
-	user, err := services.GetUserProfile(req.UserID)
+	user, err := services.GetUserProfile(userID)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -104,18 +122,28 @@ func UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := middleware.UserID(r.Context())
+	if req.UserID != "" && req.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "You can only update your own profile",
+		})
+		return
+	}
+
 	// Validate
-	if req.UserID == "" || req.Email == "" {
+	if req.Email == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"message": "User ID and email are required",
+			"message": "Email is required",
 		})
 		return
 	}
 
 	// Update profile
-	user, err := services.UpdateUserProfile(req.UserID, req.FullName, req.Phone, req.Email)
+	user, err := services.UpdateUserProfile(auditActor(r), req.FullName, req.Phone, req.Email)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -158,8 +186,18 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID := middleware.UserID(r.Context())
+	if req.UserID != "" && req.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "You can only change your own password",
+		})
+		return
+	}
+
 	// Validate
-	if req.UserID == "" || req.OldPassword == "" || req.NewPassword == "" {
+	if req.OldPassword == "" || req.NewPassword == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -178,7 +216,7 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Change password
-	if err := services.ChangePassword(req.UserID, req.OldPassword, req.NewPassword); err != nil {
+	if err := services.ChangePassword(auditActor(r), req.OldPassword, req.NewPassword); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -194,3 +232,115 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 		"message": "Password changed successfully",
 	})
 }
+
+// RequestAccountDeletion handler - verifikasi password lalu kirim kode konfirmasi ke email
+func RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.RequestAccountDeletion(middleware.UserID(r.Context()), req.Password, req.Code); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "We sent a confirmation code to your email",
+	})
+}
+
+// ConfirmAccountDeletion handler - hapus & anonimkan akun dengan kode dari email
+func ConfirmAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	var req ConfirmDeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	if err := services.ConfirmAccountDeletion(auditActor(r), req.Token); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Your account has been deleted",
+	})
+}
+
+// ExportAccountData handler - unduh ZIP berisi data pribadi user
+func ExportAccountData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Method not allowed",
+		})
+		return
+	}
+
+	// ZIP dibuat di memori dulu supaya error masih bisa dikirim sebagai JSON
+	var buf bytes.Buffer
+	if err := services.ExportUserData(auditActor(r), &buf); err != nil {
+		log.Printf("❌ Data export failed for %s: %v", middleware.UserID(r.Context()), err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Failed to export data",
+		})
+		return
+	}
+
+	filename := "sk8consign-export-" + time.Now().Format("20060102") + ".zip"
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
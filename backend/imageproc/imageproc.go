@@ -0,0 +1,197 @@
+// Package imageproc - pipeline foto produk: decode, auto-orient sesuai EXIF,
+// buang semua metadata (EXIF/GPS), resize ke beberapa ukuran, encode JPEG + WebP,
+// dan hitung blurhash untuk placeholder di client.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // decoder PNG
+	"sort"
+
+	"github.com/buckket/go-blurhash"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decoder WebP
+)
+
+// Format output varian
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+const (
+	jpegQuality = 82
+	webpQuality = 80
+
+	// Tolak gambar raksasa sebelum di-decode (decompression bomb)
+	maxSourcePixels = 50_000_000
+	maxSourceEdge   = 12000
+
+	// Komponen blurhash: 4x3 cukup untuk placeholder dan tetap pendek (~28 karakter)
+	blurHashX = 4
+	blurHashY = 3
+)
+
+// ErrImageTooLarge - dimensi gambar melebihi batas
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// Size - ukuran varian; sisi terpanjang dibatasi MaxEdge (tidak pernah di-upscale)
+type Size struct {
+	Name    string
+	MaxEdge int
+}
+
+// Sizes - varian yang dibuat untuk setiap foto, dari terbesar ke terkecil
+var Sizes = []Size{
+	{Name: "large", MaxEdge: 1600},
+	{Name: "medium", MaxEdge: 800},
+	{Name: "thumbnail", MaxEdge: 320},
+}
+
+// Variant - satu file hasil encode
+type Variant struct {
+	Size        string
+	Format      string
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Result - hasil pipeline untuk satu foto
+type Result struct {
+	Width    int // dimensi varian terbesar (sudah di-orient)
+	Height   int
+	BlurHash string
+	Variants []Variant
+}
+
+// Process - jalankan pipeline untuk satu file JPEG / PNG / WebP. Output tidak
+// membawa metadata apa pun karena setiap varian di-encode ulang dari pixel.
+func Process(data []byte) (*Result, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, errors.New("cannot read image: empty dimensions")
+	}
+	if config.Width > maxSourceEdge || config.Height > maxSourceEdge || config.Width*config.Height > maxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image: %w", err)
+	}
+
+	orientation := jpegOrientation(data)
+
+	sizes := append([]Size(nil), Sizes...)
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].MaxEdge > sizes[j].MaxEdge })
+
+	result := &Result{}
+	var previous image.Image
+
+	for i, size := range sizes {
+		var scaled *image.RGBA
+		if i == 0 {
+			// Resize dulu baru di-orient supaya rotasi dikerjakan di gambar kecil.
+			// Transparansi diratakan ke putih karena JPEG tidak punya alpha.
+			scaled = fit(src, size.MaxEdge, true)
+			scaled = orient(scaled, orientation)
+			result.Width, result.Height = scaled.Rect.Dx(), scaled.Rect.Dy()
+		} else {
+			// Varian kecil diturunkan dari varian sebelumnya (lebih cepat dari sumber 12MP)
+			scaled = fit(previous, size.MaxEdge, false)
+		}
+		previous = scaled
+
+		variants, err := encodeVariants(size.Name, scaled)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, variants...)
+	}
+
+	result.BlurHash, err = blurhash.Encode(blurHashX, blurHashY, previous)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute blurhash: %w", err)
+	}
+
+	return result, nil
+}
+
+func encodeVariants(size string, img *image.RGBA) ([]Variant, error) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("cannot encode %s jpeg: %w", size, err)
+	}
+
+	variants := []Variant{{
+		Size:        size,
+		Format:      FormatJPEG,
+		ContentType: "image/jpeg",
+		Extension:   ".jpg",
+		Width:       width,
+		Height:      height,
+		Data:        jpegData.Bytes(),
+	}}
+
+	if WebPSupported {
+		webpData, err := encodeWebP(img, webpQuality)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %s webp: %w", size, err)
+		}
+		variants = append(variants, Variant{
+			Size:        size,
+			Format:      FormatWebP,
+			ContentType: "image/webp",
+			Extension:   ".webp",
+			Width:       width,
+			Height:      height,
+			Data:        webpData,
+		})
+	}
+
+	return variants, nil
+}
+
+// fit - skala img supaya sisi terpanjang <= maxEdge (tanpa upscale)
+func fit(img image.Image, maxEdge int, flatten bool) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > maxEdge || height > maxEdge {
+		if width >= height {
+			height = max(1, height*maxEdge/width)
+			width = maxEdge
+		} else {
+			width = max(1, width*maxEdge/height)
+			height = maxEdge
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	op := draw.Src
+	if flatten {
+		draw.Draw(dst, dst.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+		op = draw.Over
+	}
+
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Rect, img, bounds.Min, op)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Rect, img, bounds, op, nil)
+	}
+
+	return dst
+}
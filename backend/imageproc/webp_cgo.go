@@ -0,0 +1,16 @@
+//go:build cgo
+
+package imageproc
+
+import (
+	"image"
+
+	"github.com/chai2010/webp"
+)
+
+// WebPSupported - encoder WebP (libwebp) tersedia di build ini
+const WebPSupported = true
+
+func encodeWebP(img image.Image, quality int) ([]byte, error) {
+	return webp.EncodeRGB(img, float32(quality))
+}
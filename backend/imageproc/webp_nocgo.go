@@ -0,0 +1,16 @@
+//go:build !cgo
+
+package imageproc
+
+import (
+	"errors"
+	"image"
+)
+
+// WebPSupported - build tanpa cgo tidak punya encoder WebP; hanya varian JPEG yang
+// dibuat. main menolak start di luar development jika false.
+const WebPSupported = false
+
+func encodeWebP(img image.Image, quality int) ([]byte, error) {
+	return nil, errors.New("webp encoding requires cgo")
+}
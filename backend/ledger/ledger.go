@@ -0,0 +1,280 @@
+// Package ledger - double-entry ledger untuk semua pergerakan uang.
+// Setiap fungsi Post* menerima *gorm.DB transaksi milik caller supaya jurnal
+// tersimpan atomik bersama perubahan order / payout.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"sk8consign-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kode akun
+const (
+	AccountCash             = "cash"
+	AccountBuyerReceivable  = "buyer_receivable"
+	AccountConsignorPayable = "consignor_payable"
+	AccountPlatformRevenue  = "platform_revenue"
+	AccountRefunds          = "refunds"
+)
+
+// Jenis jurnal
+const (
+	EntryPayment    = "payment"
+	EntryCommission = "commission"
+	EntryPayout     = "payout"
+	EntryRefund     = "refund"
+)
+
+var ErrUnbalanced = errors.New("journal entry is not balanced")
+
+// ChartOfAccounts - daftar akun yang dipakai platform
+var ChartOfAccounts = []models.LedgerAccount{
+	{Code: AccountCash, Name: "Cash / Payment Gateway Clearing", Type: "asset", NormalBalance: "debit"},
+	{Code: AccountBuyerReceivable, Name: "Buyer Receivable", Type: "asset", NormalBalance: "debit"},
+	{Code: AccountConsignorPayable, Name: "Consignor Payable", Type: "liability", NormalBalance: "credit"},
+	{Code: AccountPlatformRevenue, Name: "Platform Commission Revenue", Type: "revenue", NormalBalance: "credit"},
+	{Code: AccountRefunds, Name: "Refunds", Type: "contra_revenue", NormalBalance: "debit"},
+}
+
+// Line - satu baris jurnal sebelum disimpan
+type Line struct {
+	Account string
+	PartyID string
+	Debit   float64
+	Credit  float64
+}
+
+// Debit - buat baris debit
+func Debit(account string, amount float64, partyID string) Line {
+	return Line{Account: account, PartyID: partyID, Debit: amount}
+}
+
+// Credit - buat baris credit
+func Credit(account string, amount float64, partyID string) Line {
+	return Line{Account: account, PartyID: partyID, Credit: amount}
+}
+
+// SeedAccounts - pastikan chart of accounts ada di database
+func SeedAccounts(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ChartOfAccounts).Error
+}
+
+// Post - simpan jurnal setelah memastikan debit dan credit balance
+func Post(tx *gorm.DB, entryType, referenceType, referenceID, description string, lines ...Line) (*models.JournalEntry, error) {
+	if len(lines) < 2 {
+		return nil, errors.New("journal entry needs at least two lines")
+	}
+
+	var debit, credit int64
+	for _, line := range lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return nil, errors.New("journal line amounts cannot be negative")
+		}
+		if (line.Debit == 0) == (line.Credit == 0) {
+			return nil, errors.New("journal line must be either a debit or a credit")
+		}
+		if !isKnownAccount(line.Account) {
+			return nil, fmt.Errorf("unknown ledger account %q", line.Account)
+		}
+		debit += toCents(line.Debit)
+		credit += toCents(line.Credit)
+	}
+
+	if debit != credit {
+		return nil, fmt.Errorf("%w: debit %.2f, credit %.2f", ErrUnbalanced, fromCents(debit), fromCents(credit))
+	}
+
+	entry := &models.JournalEntry{
+		ID:            uuid.New().String(),
+		EntryType:     entryType,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Description:   description,
+	}
+
+	for _, line := range lines {
+		var partyID *string
+		if line.PartyID != "" {
+			party := line.PartyID
+			partyID = &party
+		}
+
+		entry.Lines = append(entry.Lines, models.JournalLine{
+			ID:          uuid.New().String(),
+			EntryID:     entry.ID,
+			AccountCode: line.Account,
+			PartyID:     partyID,
+			Debit:       fromCents(toCents(line.Debit)),
+			Credit:      fromCents(toCents(line.Credit)),
+		})
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Proceeds - hasil penjualan satu consignor di dalam order
+type Proceeds struct {
+	ConsignorID string
+	Gross       float64
+	Commission  float64
+}
+
+// PostPayment - catat pembayaran buyer: piutang buyer timbul dan langsung
+// dilunasi, hasil penjualan kotor menjadi utang ke consignor.
+func PostPayment(tx *gorm.DB, orderID, buyerID string, amount float64, proceeds []Proceeds) (*models.JournalEntry, error) {
+	lines := []Line{Debit(AccountBuyerReceivable, amount, buyerID)}
+	for _, p := range proceeds {
+		lines = append(lines, Credit(AccountConsignorPayable, p.Gross, p.ConsignorID))
+	}
+	lines = append(lines,
+		Debit(AccountCash, amount, ""),
+		Credit(AccountBuyerReceivable, amount, buyerID),
+	)
+
+	return Post(tx, EntryPayment, "order", orderID, "Payment received for order "+orderID, lines...)
+}
+
+// PostCommission - pindahkan komisi platform dari utang consignor ke pendapatan
+func PostCommission(tx *gorm.DB, orderID string, proceeds []Proceeds) (*models.JournalEntry, error) {
+	var lines []Line
+	var total float64
+	for _, p := range proceeds {
+		if toCents(p.Commission) == 0 {
+			continue
+		}
+		lines = append(lines, Debit(AccountConsignorPayable, p.Commission, p.ConsignorID))
+		total += p.Commission
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	lines = append(lines, Credit(AccountPlatformRevenue, total, ""))
+	return Post(tx, EntryCommission, "order", orderID, "Platform commission for order "+orderID, lines...)
+}
+
+// PostPayout - transfer bagian consignor keluar dari kas
+func PostPayout(tx *gorm.DB, payoutID, consignorID string, amount float64) (*models.JournalEntry, error) {
+	return Post(tx, EntryPayout, "payout", payoutID, "Payout to consignor",
+		Debit(AccountConsignorPayable, amount, consignorID),
+		Credit(AccountCash, amount, ""),
+	)
+}
+
+// PostRefund - kembalikan uang ke buyer. Bagian consignor mengurangi utang
+// consignor, bagian komisi dicatat di akun refunds (contra revenue).
+func PostRefund(tx *gorm.DB, referenceType, referenceID, buyerID, consignorID string, consignorShare, commissionShare float64) (*models.JournalEntry, error) {
+	amount := fromCents(toCents(consignorShare) + toCents(commissionShare))
+
+	var lines []Line
+	if toCents(consignorShare) > 0 {
+		lines = append(lines, Debit(AccountConsignorPayable, consignorShare, consignorID))
+	}
+	if toCents(commissionShare) > 0 {
+		lines = append(lines, Debit(AccountRefunds, commissionShare, ""))
+	}
+	lines = append(lines, Credit(AccountCash, amount, buyerID))
+
+	return Post(tx, EntryRefund, referenceType, referenceID, "Refund to buyer", lines...)
+}
+
+// AccountBalance - satu baris trial balance
+type AccountBalance struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	NormalBalance string  `json:"normal_balance"`
+	TotalDebit    float64 `json:"total_debit"`
+	TotalCredit   float64 `json:"total_credit"`
+	Balance       float64 `json:"balance"`
+}
+
+// TrialBalanceReport - hasil trial balance
+type TrialBalanceReport struct {
+	AsOf        time.Time        `json:"as_of"`
+	Accounts    []AccountBalance `json:"accounts"`
+	TotalDebit  float64          `json:"total_debit"`
+	TotalCredit float64          `json:"total_credit"`
+	Balanced    bool             `json:"balanced"`
+}
+
+// TrialBalance - total debit / credit per akun sampai waktu asOf
+func TrialBalance(db *gorm.DB, asOf time.Time) (*TrialBalanceReport, error) {
+	var rows []struct {
+		AccountCode string
+		Debit       float64
+		Credit      float64
+	}
+
+	err := db.Model(&models.JournalLine{}).
+		Select("account_code, COALESCE(SUM(debit), 0) AS debit, COALESCE(SUM(credit), 0) AS credit").
+		Where("created_at <= ?", asOf).
+		Group("account_code").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string][2]int64, len(rows))
+	for _, row := range rows {
+		totals[row.AccountCode] = [2]int64{toCents(row.Debit), toCents(row.Credit)}
+	}
+
+	report := &TrialBalanceReport{AsOf: asOf}
+	var debit, credit int64
+	for _, account := range ChartOfAccounts {
+		t := totals[account.Code]
+		balance := t[0] - t[1]
+		if account.NormalBalance == "credit" {
+			balance = -balance
+		}
+
+		report.Accounts = append(report.Accounts, AccountBalance{
+			Code:          account.Code,
+			Name:          account.Name,
+			Type:          account.Type,
+			NormalBalance: account.NormalBalance,
+			TotalDebit:    fromCents(t[0]),
+			TotalCredit:   fromCents(t[1]),
+			Balance:       fromCents(balance),
+		})
+		debit += t[0]
+		credit += t[1]
+	}
+
+	report.TotalDebit = fromCents(debit)
+	report.TotalCredit = fromCents(credit)
+	report.Balanced = debit == credit
+
+	return report, nil
+}
+
+func isKnownAccount(code string) bool {
+	for _, account := range ChartOfAccounts {
+		if account.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogMailer - mailer untuk development / test: email ditulis ke log dan,
+// jika Dir diisi, disimpan sebagai file .eml. Sent menyimpan semua pesan terkirim.
+type LogMailer struct {
+	From string
+	Dir  string
+
+	mu   sync.Mutex
+	Sent []Message
+}
+
+// NewLogMailer - buat log mailer; dir kosong berarti hanya log
+func NewLogMailer(from, dir string) *LogMailer {
+	return &LogMailer{From: from, Dir: dir}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.Sent = append(m.Sent, msg)
+	m.mu.Unlock()
+
+	log.Printf("📧 Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), recipient)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), encode(m.From, msg), 0o644)
+}
+
+// Last - pesan terakhir yang dikirim ke alamat tertentu
+func (m *LogMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.Sent) - 1; i >= 0; i-- {
+		if m.Sent[i].To == to {
+			return m.Sent[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,45 @@
+// Package mailer - pengiriman email di balik interface Mailer.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message - satu email teks
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - kontrak pengirim email
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// encode - format RFC 5322 sederhana (text/plain UTF-8)
+func encode(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validHeader - tolak CR/LF supaya input user tidak bisa menyisipkan header
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("invalid email header value")
+		}
+	}
+	return nil
+}
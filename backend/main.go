@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"sk8consign-backend/config"
 	"sk8consign-backend/database"
 	"sk8consign-backend/handlers"
+	"sk8consign-backend/imageproc"
+	"sk8consign-backend/mailer"
 	"sk8consign-backend/middleware"
+	"sk8consign-backend/payment"
+	"sk8consign-backend/rbac"
+	"sk8consign-backend/search"
+	"sk8consign-backend/services"
+	"sk8consign-backend/storage"
+	"sk8consign-backend/utils"
 
 	"github.com/rs/cors"
 )
@@ -33,11 +44,35 @@ func main() {
 		database.SeedData()
 	}
 
+	// Load JWT signing keys (reload dengan SIGHUP)
+	setupKeyRing()
+
+	// Setup payment provider
+	setupPaymentProvider()
+
+	// Setup mailer
+	setupMailer()
+
+	// Setup upload storage
+	setupBlobStore()
+
+	// Setup full-text search
+	setupSearchIndex()
+
+	// Register domain event subscribers
+	services.RegisterNotificationSubscriber(services.Events)
+
+	// Release reservations of unpaid orders in the background
+	go services.RunReservationWorker(context.Background(), config.AppConfig.ReservationSweepInterval)
+
+	// Retry gateway refunds that were recorded but not yet sent to the provider
+	go services.RunRefundWorker(context.Background(), config.AppConfig.RefundRetryInterval)
+
 	// Setup routes
 	mux := setupRoutes()
 
 	// CORS middleware
-	handler := setupCORS(mux)
+	handler := setupCORS(middleware.StripIdentityHeaders(mux))
 
 	// Start server
 	startServer(handler)
@@ -48,44 +83,235 @@ func setupRoutes() *http.ServeMux {
 
 	authHandler := handlers.NewAuthHandler()
 
-	mux.HandleFunc("/api/login", authHandler.Login)
-	mux.HandleFunc("/api/register", authHandler.Register)
+	mux.HandleFunc("/api/login", middleware.Public(authHandler.Login))
+	mux.HandleFunc("/api/register", middleware.Public(authHandler.Register))
+	mux.HandleFunc("/api/auth/refresh", middleware.Public(authHandler.Refresh))
+	mux.HandleFunc("/api/auth/logout", middleware.Public(authHandler.Logout))
+	mux.HandleFunc("/api/auth/verify-email", middleware.Public(authHandler.VerifyEmail))
+	mux.HandleFunc("/api/auth/verify-email/request", middleware.RequirePermission(rbac.PermAccountManage, authHandler.RequestEmailVerification))
+	mux.HandleFunc("/api/auth/password-reset/request", middleware.Public(authHandler.RequestPasswordReset))
+	mux.HandleFunc("/api/auth/password-reset/confirm", middleware.Public(authHandler.ConfirmPasswordReset))
+	mux.HandleFunc("/api/auth/2fa/login", middleware.Public(authHandler.LoginTwoFactor))
+	mux.HandleFunc("/api/auth/2fa/enroll", middleware.RequirePermission(rbac.PermAccountManage, authHandler.EnrollTwoFactor))
+	mux.HandleFunc("/api/auth/2fa/verify", middleware.RequirePermission(rbac.PermAccountManage, authHandler.VerifyTwoFactor))
+	mux.HandleFunc("/api/auth/2fa/disable", middleware.RequirePermission(rbac.PermAccountManage, authHandler.DisableTwoFactor))
+	mux.HandleFunc("/api/auth/2fa/recovery-codes", middleware.RequirePermission(rbac.PermAccountManage, authHandler.RecoveryCodes))
+
+	mux.HandleFunc("/api/profile", middleware.RequirePermission(rbac.PermAccountManage, handlers.GetProfile))
+	mux.HandleFunc("/api/profile/update", middleware.RequirePermission(rbac.PermAccountManage, handlers.UpdateProfile))
+	mux.HandleFunc("/api/profile/change-password", middleware.RequirePermission(rbac.PermAccountManage, handlers.ChangePassword))
+	mux.HandleFunc("/api/profile/delete/request", middleware.RequirePermission(rbac.PermAccountManage, handlers.RequestAccountDeletion))
+	mux.HandleFunc("/api/profile/delete/confirm", middleware.RequirePermission(rbac.PermAccountManage, handlers.ConfirmAccountDeletion))
+	mux.HandleFunc("/api/profile/export", middleware.RequirePermission(rbac.PermAccountManage, handlers.ExportAccountData))
+
+	mux.HandleFunc("/api/products/search", middleware.Public(handlers.SearchProducts))
+	mux.HandleFunc("/api/products/detail", middleware.Public(handlers.GetProductDetail))
+	mux.HandleFunc("/api/products/user", middleware.RequirePermission(rbac.PermAccountManage, handlers.GetUserProducts))
+	mux.HandleFunc("/api/products/create", middleware.RequirePermission(rbac.PermProductCreate, handlers.CreateProduct))
+	mux.HandleFunc("/api/products/update", middleware.RequirePermission(rbac.PermProductUpdate, handlers.UpdateProduct))
+	mux.HandleFunc("/api/products/delete", middleware.RequirePermission(rbac.PermProductDelete, handlers.DeleteProduct))
+	mux.HandleFunc("/api/products/categories", middleware.Public(handlers.GetCategories))
+	mux.HandleFunc("/api/products/images/upload", middleware.RequirePermission(rbac.PermProductUpdate, handlers.UploadProductImage))
+	mux.HandleFunc("/api/products/images/delete", middleware.RequirePermission(rbac.PermProductUpdate, handlers.DeleteProductImage))
+	mux.HandleFunc("/api/products/images/primary", middleware.RequirePermission(rbac.PermProductUpdate, handlers.SetPrimaryProductImage))
+	mux.HandleFunc("/api/products/images/reorder", middleware.RequirePermission(rbac.PermProductUpdate, handlers.ReorderProductImages))
+	mux.HandleFunc("/api/admin/products/pending", middleware.RequirePermission(rbac.PermProductApprove, handlers.GetProductReviewQueue))
+	mux.HandleFunc("/api/admin/products/review", middleware.RequirePermission(rbac.PermProductApprove, handlers.ReviewProduct))
+
+	mux.HandleFunc("/api/cart", middleware.RequirePermission(rbac.PermOrderCreate, handlers.GetCart))
+	mux.HandleFunc("/api/cart/add", middleware.RequirePermission(rbac.PermOrderCreate, handlers.AddToCart))
+	mux.HandleFunc("/api/cart/update", middleware.RequirePermission(rbac.PermOrderCreate, handlers.UpdateCart))
+	mux.HandleFunc("/api/cart/remove", middleware.RequirePermission(rbac.PermOrderCreate, handlers.RemoveFromCart))
+	mux.HandleFunc("/api/cart/clear", middleware.RequirePermission(rbac.PermOrderCreate, handlers.ClearCart))
+
+	mux.HandleFunc("/api/orders", middleware.RequirePermission(rbac.PermOrderRead, handlers.GetUserOrders))
+	mux.HandleFunc("/api/orders/create", middleware.RequirePermission(rbac.PermOrderCreate, handlers.CreateOrder))
+	mux.HandleFunc("/api/orders/detail", middleware.RequirePermission(rbac.PermOrderRead, handlers.GetOrderDetail))
+	mux.HandleFunc("/api/orders/update-status", middleware.RequirePermission(rbac.PermOrderUpdate, handlers.UpdateOrderStatus))
+
+	mux.HandleFunc("/api/payments/intent", middleware.RequirePermission(rbac.PermOrderCreate, handlers.CreatePaymentIntent))
+	mux.HandleFunc("/api/payments/webhook", middleware.Public(handlers.PaymentWebhook))
+	if config.AppConfig.Env == "development" && config.AppConfig.PaymentProvider == "fake" {
+		mux.HandleFunc("/api/payments/fake/pay", middleware.RequirePermission(rbac.PermOrderCreate, handlers.SimulateFakePayment))
+	}
+
+	mux.HandleFunc("/api/seller/orders", middleware.RequirePermission(rbac.PermOrderFulfil, handlers.GetSellerOrders))
+	mux.HandleFunc("/api/seller/orders/detail", middleware.RequirePermission(rbac.PermOrderFulfil, handlers.GetSellerOrderDetail))
+	mux.HandleFunc("/api/seller/orders/ship", middleware.RequirePermission(rbac.PermOrderFulfil, handlers.ShipSellerOrder))
+	mux.HandleFunc("/api/seller/orders/cancel", middleware.RequirePermission(rbac.PermOrderFulfil, handlers.CancelSellerOrder))
 
-	mux.HandleFunc("/api/profile", middleware.AuthMiddleware(handlers.GetProfile))
-	mux.HandleFunc("/api/profile/update", middleware.AuthMiddleware(handlers.UpdateProfile))
-	mux.HandleFunc("/api/profile/change-password", middleware.AuthMiddleware(handlers.ChangePassword))
+	mux.HandleFunc("/api/returns", middleware.RequirePermission(rbac.PermOrderRead, handlers.GetMyReturns))
+	mux.HandleFunc("/api/returns/create", middleware.RequirePermission(rbac.PermOrderCreate, handlers.CreateReturn))
+	mux.HandleFunc("/api/returns/review", middleware.RequirePermission(rbac.PermReturnReview, handlers.ReviewReturn))
+	mux.HandleFunc("/api/seller/returns", middleware.RequirePermission(rbac.PermReturnReview, handlers.GetSellerReturns))
+	mux.HandleFunc("/api/admin/returns", middleware.RequirePermission(rbac.PermReturnManage, handlers.GetAllReturns))
+	mux.HandleFunc("/api/admin/orders/refund", middleware.RequirePermission(rbac.PermOrderRefund, handlers.RefundOrder))
 
-	mux.HandleFunc("/api/products/search", handlers.SearchProducts)
-	mux.HandleFunc("/api/products/detail", handlers.GetProductDetail)
-	mux.HandleFunc("/api/products/user", handlers.GetUserProducts)
-	mux.HandleFunc("/api/products/create", middleware.RequireAdmin(handlers.CreateProduct))
-	mux.HandleFunc("/api/products/update", middleware.RequireAuth(handlers.UpdateProduct))
-	mux.HandleFunc("/api/products/delete", middleware.RequireAuth(handlers.DeleteProduct))
-	mux.HandleFunc("/api/products/categories", handlers.GetCategories)
+	mux.HandleFunc("/api/consignments", middleware.RequirePermission(rbac.PermConsignmentRead, handlers.GetMyConsignments))
+	mux.HandleFunc("/api/consignments/create", middleware.RequirePermission(rbac.PermConsignmentManage, handlers.CreateConsignmentAgreement))
+	mux.HandleFunc("/api/seller/earnings", middleware.RequirePermission(rbac.PermConsignmentRead, handlers.GetSellerEarnings))
+	mux.HandleFunc("/api/seller/payouts", middleware.RequirePermission(rbac.PermConsignmentRead, handlers.GetSellerPayouts))
+	mux.HandleFunc("/api/admin/payouts/mark-paid", middleware.RequirePermission(rbac.PermPayoutManage, handlers.MarkPayoutPaid))
+	mux.HandleFunc("/api/admin/ledger/trial-balance", middleware.RequirePermission(rbac.PermLedgerRead, handlers.GetTrialBalance))
 
-	mux.HandleFunc("/api/cart", middleware.AuthMiddleware(handlers.GetCart))
-	mux.HandleFunc("/api/cart/add", middleware.AuthMiddleware(handlers.AddToCart))
-	mux.HandleFunc("/api/cart/update", middleware.AuthMiddleware(handlers.UpdateCart))
-	mux.HandleFunc("/api/cart/remove", middleware.AuthMiddleware(handlers.RemoveFromCart))
-	mux.HandleFunc("/api/cart/clear", middleware.AuthMiddleware(handlers.ClearCart))
+	mux.HandleFunc("/api/admin/users", middleware.RequirePermission(rbac.PermUserRead, handlers.ListUsers))
+	mux.HandleFunc("/api/admin/users/detail", middleware.RequirePermission(rbac.PermUserRead, handlers.GetUserDetail))
+	mux.HandleFunc("/api/admin/users/products", middleware.RequirePermission(rbac.PermUserRead, handlers.GetUserProductsAdmin))
+	mux.HandleFunc("/api/admin/users/orders", middleware.RequirePermission(rbac.PermUserRead, handlers.GetUserOrdersAdmin))
+	mux.HandleFunc("/api/admin/users/suspend", middleware.RequirePermission(rbac.PermUserBan, handlers.SuspendUser))
+	mux.HandleFunc("/api/admin/users/reactivate", middleware.RequirePermission(rbac.PermUserBan, handlers.ReactivateUser))
+	mux.HandleFunc("/api/admin/users/force-password-reset", middleware.RequirePermission(rbac.PermUserBan, handlers.ForcePasswordReset))
+	mux.HandleFunc("/api/admin/roles", middleware.RequirePermission(rbac.PermRoleAssign, handlers.GetRoles))
+	mux.HandleFunc("/api/admin/users/role", middleware.RequirePermission(rbac.PermRoleAssign, handlers.AssignUserRole))
+	mux.HandleFunc("/api/admin/users/unlock", middleware.RequirePermission(rbac.PermUserUnlock, handlers.UnlockUser))
+	mux.HandleFunc("/api/admin/2fa/policy", middleware.RequirePermission(rbac.PermSecurityManage, handlers.TwoFactorPolicy))
+	mux.HandleFunc("/api/admin/audit", middleware.RequirePermission(rbac.PermAuditRead, handlers.ListAuditLogs))
 
-	mux.HandleFunc("/api/orders", middleware.AuthMiddleware(handlers.GetUserOrders))
-	mux.HandleFunc("/api/orders/create", middleware.AuthMiddleware(handlers.CreateOrder))
-	mux.HandleFunc("/api/orders/detail", middleware.AuthMiddleware(handlers.GetOrderDetail))
-	mux.HandleFunc("/api/orders/update-status", middleware.AuthMiddleware(handlers.UpdateOrderStatus))
-	mux.HandleFunc("/api/orders/update-payment", middleware.AuthMiddleware(handlers.UpdatePaymentStatus))
+	mux.HandleFunc("/api/notifications", middleware.RequirePermission(rbac.PermAccountManage, handlers.GetNotifications))
+	mux.HandleFunc("/api/notifications/read", middleware.RequirePermission(rbac.PermAccountManage, handlers.MarkNotificationRead))
+	mux.HandleFunc("/api/notifications/read-all", middleware.RequirePermission(rbac.PermAccountManage, handlers.MarkAllNotificationsRead))
+	mux.HandleFunc("/api/notifications/unread-count", middleware.RequirePermission(rbac.PermAccountManage, handlers.GetUnreadCount))
+	mux.HandleFunc("/api/notifications/stream", middleware.RequirePermission(rbac.PermAccountManage, handlers.StreamNotifications))
 
-	mux.HandleFunc("/api/notifications", middleware.AuthMiddleware(handlers.GetNotifications))
-	mux.HandleFunc("/api/notifications/read", middleware.AuthMiddleware(handlers.MarkNotificationRead))
-	mux.HandleFunc("/api/notifications/read-all", middleware.AuthMiddleware(handlers.MarkAllNotificationsRead))
-	mux.HandleFunc("/api/notifications/unread-count", middleware.AuthMiddleware(handlers.GetUnreadCount))
+	mux.HandleFunc("/api/health", middleware.Public(handlers.HealthCheck))
 
-	mux.HandleFunc("/api/health", handlers.HealthCheck)
+	// File upload disajikan langsung oleh server hanya untuk driver local
+	if local, ok := services.GetBlobStore().(*storage.LocalStore); ok {
+		mux.HandleFunc("/uploads/", middleware.Public(http.StripPrefix("/uploads", local.Handler()).ServeHTTP))
+	}
+	mux.HandleFunc("/.well-known/jwks.json", middleware.Public(handlers.JWKS))
 
 	return mux
 }
 
-func setupCORS(mux *http.ServeMux) http.Handler {
+func setupKeyRing() {
+	cfg := config.AppConfig
+
+	ring, err := utils.NewKeyRing(cfg.JWTKeysDir, cfg.JWTAlgorithm, cfg.JWTActiveKID)
+	if err != nil {
+		log.Fatalf("❌ Failed to load JWT keys: %v", err)
+	}
+	utils.SetKeyRing(ring)
+
+	log.Printf("✅ JWT signing key: %s (%s)", ring.Active().ID, ring.Active().Method.Alg())
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			if err := ring.Reload(); err != nil {
+				log.Printf("❌ JWT key reload failed, keeping current keys: %v", err)
+				continue
+			}
+			log.Printf("🔑 JWT keys reloaded, active key: %s", ring.Active().ID)
+		}
+	}()
+}
+
+func setupMailer() {
+	cfg := config.AppConfig
+
+	switch cfg.MailDriver {
+	case "smtp":
+		services.SetMailer(mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom))
+	case "log":
+		// Driver log menulis isi email (termasuk token reset password / verifikasi) ke log
+		if cfg.Env != "development" {
+			log.Fatalf("❌ MAIL_DRIVER=log is only allowed in development, use smtp when ENV=%s", cfg.Env)
+		}
+		services.SetMailer(mailer.NewLogMailer(cfg.MailFrom, cfg.MailDir))
+	default:
+		log.Fatalf("❌ Unknown mail driver: %s", cfg.MailDriver)
+	}
+
+	log.Printf("✅ Mail driver: %s", cfg.MailDriver)
+}
+
+func setupBlobStore() {
+	cfg := config.AppConfig
+
+	switch cfg.StorageDriver {
+	case "local":
+		baseURL := cfg.UploadBaseURL
+		if baseURL == "" {
+			baseURL = cfg.AppBaseURL + "/uploads"
+		}
+		store, err := storage.NewLocalStore(cfg.UploadDir, baseURL)
+		if err != nil {
+			log.Fatalf("❌ Failed to prepare upload dir: %v", err)
+		}
+		services.SetBlobStore(store, int64(cfg.UploadMaxBytes))
+	case "s3":
+		services.SetBlobStore(storage.NewS3Store(
+			cfg.S3Endpoint,
+			cfg.S3Region,
+			cfg.S3Bucket,
+			cfg.S3AccessKey,
+			cfg.S3SecretKey,
+			cfg.S3PublicURL,
+		), int64(cfg.UploadMaxBytes))
+	default:
+		log.Fatalf("❌ Unknown storage driver: %s", cfg.StorageDriver)
+	}
+
+	log.Printf("✅ Storage driver: %s", cfg.StorageDriver)
+
+	// Varian WebP foto produk butuh libwebp (cgo)
+	if !imageproc.WebPSupported {
+		if cfg.Env != "development" {
+			log.Fatalf("❌ This binary was built without cgo and cannot create WebP image variants, rebuild with CGO_ENABLED=1 when ENV=%s", cfg.Env)
+		}
+		log.Println("⚠️  Built without cgo: product photos get JPEG variants only (no WebP)")
+	}
+}
+
+func setupSearchIndex() {
+	cfg := config.AppConfig
+
+	switch cfg.SearchDriver {
+	case "mysql":
+		services.SetSearchIndex(search.NewMySQLIndex(database.DB))
+	case "bleve":
+		index, err := search.NewBleveIndex(cfg.SearchIndexPath)
+		if err != nil {
+			log.Fatalf("❌ Failed to open search index: %v", err)
+		}
+		services.SetSearchIndex(index)
+
+		indexed, err := services.ReindexProducts(context.Background())
+		if err != nil {
+			log.Fatalf("❌ Failed to build search index: %v", err)
+		}
+		log.Printf("   Indexed %d products", indexed)
+	default:
+		log.Fatalf("❌ Unknown search driver: %s", cfg.SearchDriver)
+	}
+
+	log.Printf("✅ Search driver: %s", cfg.SearchDriver)
+}
+
+func setupPaymentProvider() {
+	cfg := config.AppConfig
+
+	switch cfg.PaymentProvider {
+	case "gateway":
+		services.SetPaymentProvider(payment.NewGatewayProvider(
+			cfg.PaymentAPIURL,
+			cfg.PaymentAPIKey,
+			cfg.PaymentWebhookSecret,
+			cfg.AppBaseURL+"/api/payments/webhook",
+		))
+	case "fake":
+		services.SetPaymentProvider(payment.NewFakeProvider(cfg.PaymentWebhookSecret, ""))
+	default:
+		log.Fatalf("❌ Unknown payment provider: %s", cfg.PaymentProvider)
+	}
+
+	log.Printf("✅ Payment provider: %s", cfg.PaymentProvider)
+}
+
+func setupCORS(mux http.Handler) http.Handler {
 	// Get allowed origins from env or use default
 	allowedOrigins := []string{"*"}
 	if config.AppConfig.Env == "production" {
@@ -99,7 +325,7 @@ func setupCORS(mux *http.ServeMux) http.Handler {
 	return cors.New(cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Requested-With", "Last-Event-ID"},
 		ExposedHeaders:   []string{"Content-Length", "Content-Type"},
 		AllowCredentials: true,
 		MaxAge:           300, // 5 minutes
@@ -115,12 +341,27 @@ func startServer(handler http.Handler) {
 	log.Println("📱 Available Endpoints:")
 	log.Println("   [Auth]")
 	log.Println("   POST   /api/register")
+	log.Println("   POST   /api/auth/refresh")
+	log.Println("   POST   /api/auth/logout")
+	log.Println("   GET    /api/auth/verify-email")
+	log.Println("   POST   /api/auth/verify-email/request")
+	log.Println("   POST   /api/auth/password-reset/request")
+	log.Println("   POST   /api/auth/password-reset/confirm")
+	log.Println("   POST   /api/auth/2fa/login")
+	log.Println("   POST   /api/auth/2fa/enroll")
+	log.Println("   POST   /api/auth/2fa/verify")
+	log.Println("   POST   /api/auth/2fa/disable")
+	log.Println("   GET    /api/auth/2fa/recovery-codes")
+	log.Println("   POST   /api/auth/2fa/recovery-codes")
 	log.Println("   POST   /api/login")
 	log.Println()
 	log.Println("   [Profile]")
-	log.Println("   POST   /api/profile")
+	log.Println("   GET    /api/profile")
 	log.Println("   PUT    /api/profile/update")
 	log.Println("   PUT    /api/profile/change-password")
+	log.Println("   POST   /api/profile/delete/request")
+	log.Println("   POST   /api/profile/delete/confirm")
+	log.Println("   GET    /api/profile/export (ZIP)")
 	log.Println()
 	log.Println("   [Products]")
 	log.Println("   POST   /api/products/search")
@@ -130,6 +371,14 @@ func startServer(handler http.Handler) {
 	log.Println("   PUT    /api/products/update")
 	log.Println("   DELETE /api/products/delete")
 	log.Println("   GET    /api/products/categories")
+	log.Println("   POST   /api/products/images/upload (multipart)")
+	log.Println("   DELETE /api/products/images/delete")
+	log.Println("   PUT    /api/products/images/primary")
+	log.Println("   PUT    /api/products/images/reorder")
+	log.Println()
+	log.Println("   [Product Review]")
+	log.Println("   GET    /api/admin/products/pending")
+	log.Println("   PUT    /api/admin/products/review")
 	log.Println()
 	log.Println("   [Cart]")
 	log.Println("   GET    /api/cart")
@@ -143,16 +392,60 @@ func startServer(handler http.Handler) {
 	log.Println("   POST   /api/orders/create")
 	log.Println("   GET    /api/orders/detail")
 	log.Println("   PUT    /api/orders/update-status")
-	log.Println("   PUT    /api/orders/update-payment")
+	log.Println()
+	log.Println("   [Payments]")
+	log.Println("   POST   /api/payments/intent")
+	log.Println("   POST   /api/payments/webhook")
+	log.Println()
+	log.Println("   [Seller Orders]")
+	log.Println("   GET    /api/seller/orders")
+	log.Println("   GET    /api/seller/orders/detail")
+	log.Println("   PUT    /api/seller/orders/ship")
+	log.Println("   PUT    /api/seller/orders/cancel")
+	log.Println()
+	log.Println("   [Returns & Refunds]")
+	log.Println("   GET    /api/returns")
+	log.Println("   POST   /api/returns/create")
+	log.Println("   PUT    /api/returns/review")
+	log.Println("   GET    /api/seller/returns")
+	log.Println("   GET    /api/admin/returns")
+	log.Println("   POST   /api/admin/orders/refund")
+	log.Println()
+	log.Println("   [Consignment]")
+	log.Println("   GET    /api/consignments")
+	log.Println("   POST   /api/consignments/create")
+	log.Println("   GET    /api/seller/earnings")
+	log.Println("   GET    /api/seller/payouts")
+	log.Println("   PUT    /api/admin/payouts/mark-paid")
+	log.Println()
+	log.Println("   [Ledger]")
+	log.Println("   GET    /api/admin/ledger/trial-balance")
+	log.Println()
+	log.Println("   [Admin Users]")
+	log.Println("   GET    /api/admin/users")
+	log.Println("   GET    /api/admin/users/detail")
+	log.Println("   GET    /api/admin/users/products")
+	log.Println("   GET    /api/admin/users/orders")
+	log.Println("   PUT    /api/admin/users/suspend")
+	log.Println("   PUT    /api/admin/users/reactivate")
+	log.Println("   POST   /api/admin/users/force-password-reset")
+	log.Println("   GET    /api/admin/roles")
+	log.Println("   PUT    /api/admin/users/role")
+	log.Println("   PUT    /api/admin/users/unlock")
+	log.Println("   GET    /api/admin/2fa/policy")
+	log.Println("   PUT    /api/admin/2fa/policy")
+	log.Println("   GET    /api/admin/audit")
 	log.Println()
 	log.Println("   [Notifications]")
 	log.Println("   GET    /api/notifications")
 	log.Println("   PUT    /api/notifications/read")
 	log.Println("   PUT    /api/notifications/read-all")
 	log.Println("   GET    /api/notifications/unread-count")
+	log.Println("   GET    /api/notifications/stream (SSE)")
 	log.Println()
 	log.Println("   [System]")
 	log.Println("   GET    /api/health")
+	log.Println("   GET    /.well-known/jwks.json")
 	log.Println()
 	log.Println("💾 Database:")
 	log.Printf("   Host: %s:%s\n", config.AppConfig.DBHost, config.AppConfig.DBPort)
@@ -3,10 +3,17 @@ package middleware
 import (
 	"encoding/json"
 	"net/http"
-	"sk8consign-backend/utils"
+	"sk8consign-backend/services"
 	"strings"
 )
 
+// twoFactorSetupPaths - endpoint yang boleh diakses token setup 2FA
+var twoFactorSetupPaths = map[string]bool{
+	"/api/profile":         true,
+	"/api/auth/2fa/enroll": true,
+	"/api/auth/2fa/verify": true,
+}
+
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -31,7 +38,7 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ValidateToken(tokenParts[1])
+		claims, err := services.ValidateAccessToken(tokenParts[1])
 		if err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -42,11 +49,32 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		r.Header.Set("X-User-ID", claims.UserID)
-		r.Header.Set("X-Username", claims.Username)
-		r.Header.Set("X-Role", claims.Role)
+		// Token setup 2FA hanya boleh dipakai untuk enroll 2FA
+		if claims.TwoFactorSetup && !twoFactorSetupPaths[r.URL.Path] {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success":                   false,
+				"message":                   "Two-factor authentication is required for your account, please enable it first",
+				"two_factor_setup_required": true,
+			})
+			return
+		}
+
+		identity := Identity{
+			UserID:   claims.UserID,
+			Username: claims.Username,
+			Role:     claims.Role,
+		}
+		if claims.IssuedAt != nil {
+			identity.IssuedAt = claims.IssuedAt.Time
+		}
+		if claims.ExpiresAt != nil {
+			identity.ExpiresAt = claims.ExpiresAt.Time
+		}
+		ctx := WithIdentity(r.Context(), identity)
 
-		next(w, r)
+		next(w, r.WithContext(ctx))
 	}
 }
 
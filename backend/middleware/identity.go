@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Identity - user yang sudah terautentikasi untuk satu request
+type Identity struct {
+	UserID   string
+	Username string
+	Role     string
+
+	// Masa berlaku access token (iat / exp), untuk koneksi panjang seperti SSE
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type identityKey struct{}
+
+// WithIdentity - simpan identity di context request
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom - identity dari context; ok false jika request tidak melewati AuthMiddleware
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// UserID - ID user yang login ("" jika tidak ada)
+func UserID(ctx context.Context) string {
+	identity, _ := IdentityFrom(ctx)
+	return identity.UserID
+}
+
+// Username - username user yang login ("" jika tidak ada)
+func Username(ctx context.Context) string {
+	identity, _ := IdentityFrom(ctx)
+	return identity.Username
+}
+
+// Role - role user yang login ("" jika tidak ada)
+func Role(ctx context.Context) string {
+	identity, _ := IdentityFrom(ctx)
+	return identity.Role
+}
+
+// StripIdentityHeaders - buang header identitas dari client di pintu masuk
+// supaya tidak ada kode yang bisa tertipu header X-User-* / X-Role palsu
+func StripIdentityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name := range r.Header {
+			if isIdentityHeader(name) {
+				r.Header.Del(name)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isIdentityHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	return strings.HasPrefix(name, "X-User-") || name == "X-Username" || name == "X-Role"
+}
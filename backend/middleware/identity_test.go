@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sk8consign-backend/rbac"
+	"sk8consign-backend/utils"
+)
+
+// spoofedHeaders - header identitas palsu yang dikirim client
+var spoofedHeaders = map[string]string{
+	"X-User-ID":   "00000000-0000-0000-0000-000000000001",
+	"X-User-Role": rbac.RoleAdmin,
+	"X-Username":  "admin",
+	"X-Role":      rbac.RoleAdmin,
+}
+
+func newSpoofedRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+	for name, value := range spoofedHeaders {
+		req.Header.Set(name, value)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// identityRecorder - handler yang mencatat identity dan header yang sampai ke handler
+type identityRecorder struct {
+	called   bool
+	identity Identity
+	headers  http.Header
+}
+
+func (rec *identityRecorder) handle(w http.ResponseWriter, r *http.Request) {
+	rec.called = true
+	rec.identity, _ = IdentityFrom(r.Context())
+	rec.headers = r.Header.Clone()
+	w.WriteHeader(http.StatusOK)
+}
+
+// protect - rantai middleware seperti di main.go, dengan atau tanpa StripIdentityHeaders
+func protect(strip bool, permission rbac.Permission, rec *identityRecorder) http.Handler {
+	var handler http.Handler = RequirePermission(permission, rec.handle)
+	if strip {
+		handler = StripIdentityHeaders(handler)
+	}
+	return handler
+}
+
+func TestStripIdentityHeaders(t *testing.T) {
+	rec := &identityRecorder{}
+	req := newSpoofedRequest("")
+	req.Header.Set("x-user-email", "admin@sk8consign.com")
+	req.Header.Set("X-Request-ID", "abc")
+	req.Header.Set("Authorization", "Bearer token")
+
+	StripIdentityHeaders(http.HandlerFunc(rec.handle)).ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, name := range []string{"X-User-ID", "X-User-Role", "X-User-Email", "X-Username", "X-Role"} {
+		if value := rec.headers.Get(name); value != "" {
+			t.Errorf("header %s = %q reached the handler, want it stripped", name, value)
+		}
+	}
+	for _, name := range []string{"X-Request-ID", "Authorization"} {
+		if rec.headers.Get(name) == "" {
+			t.Errorf("header %s was stripped, want it kept", name)
+		}
+	}
+}
+
+func TestSpoofedHeadersWithoutToken(t *testing.T) {
+	for _, strip := range []bool{true, false} {
+		rec := &identityRecorder{}
+		w := httptest.NewRecorder()
+
+		protect(strip, rbac.PermUserRead, rec).ServeHTTP(w, newSpoofedRequest(""))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("strip=%v: status = %d, want %d", strip, w.Code, http.StatusUnauthorized)
+		}
+		if rec.called {
+			t.Errorf("strip=%v: handler was called without a token", strip)
+		}
+	}
+}
+
+func TestSpoofedHeadersWithInvalidToken(t *testing.T) {
+	rec := &identityRecorder{}
+	w := httptest.NewRecorder()
+
+	protect(true, rbac.PermUserRead, rec).ServeHTTP(w, newSpoofedRequest("not-a-jwt"))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if rec.called {
+		t.Error("handler was called with an invalid token")
+	}
+}
+
+func TestSpoofedHeadersWithLowPrivilegeToken(t *testing.T) {
+	setupTestDB(t)
+
+	buyer := createTestUser(t, rbac.RoleBuyer)
+	token, err := utils.GenerateToken(buyer.ID, buyer.Username, buyer.Role)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	for _, strip := range []bool{true, false} {
+		// Route admin: header X-Role admin tidak boleh menaikkan hak akses
+		rec := &identityRecorder{}
+		w := httptest.NewRecorder()
+		protect(strip, rbac.PermUserRead, rec).ServeHTTP(w, newSpoofedRequest(token))
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("strip=%v: admin route status = %d, want %d", strip, w.Code, http.StatusForbidden)
+		}
+		if rec.called {
+			t.Errorf("strip=%v: admin handler was called for a buyer", strip)
+		}
+
+		// Route buyer: identity di context hanya berasal dari JWT
+		rec = &identityRecorder{}
+		w = httptest.NewRecorder()
+		protect(strip, rbac.PermOrderCreate, rec).ServeHTTP(w, newSpoofedRequest(token))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("strip=%v: buyer route status = %d, want %d", strip, w.Code, http.StatusOK)
+		}
+
+		want := Identity{UserID: buyer.ID, Username: buyer.Username, Role: rbac.RoleBuyer}
+		got := rec.identity
+		if got.UserID != want.UserID || got.Username != want.Username || got.Role != want.Role {
+			t.Errorf("strip=%v: identity = %+v, want %+v", strip, got, want)
+		}
+		if got.ExpiresAt.IsZero() {
+			t.Errorf("strip=%v: identity has no token expiry", strip)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBOnce sync.Once
+
+func TestMain(m *testing.M) {
+	config.AppConfig = &config.Config{
+		Env:            "test",
+		AccessTokenTTL: 15 * time.Minute,
+	}
+
+	dir, err := os.MkdirTemp("", "sk8consign-jwt-keys")
+	if err != nil {
+		panic(err)
+	}
+
+	ring, err := utils.NewKeyRing(dir, utils.AlgEdDSA, "")
+	if err != nil {
+		panic(err)
+	}
+	utils.SetKeyRing(ring)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// setupTestDB - koneksi ke MySQL uji dari TEST_DATABASE_DSN; access token dicek
+// ulang ke tabel users, jadi test dengan token di-skip jika variabel tidak diisi
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set, skipping database test")
+	}
+
+	testDBOnce.Do(func() {
+		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			t.Fatalf("connect test database: %v", err)
+		}
+		database.DB = db
+		database.AutoMigrate()
+	})
+
+	if database.DB == nil {
+		t.Fatal("test database is not available")
+	}
+}
+
+// createTestUser - user aktif dengan role tertentu; dihapus setelah test selesai
+func createTestUser(t *testing.T, role string) *models.User {
+	t.Helper()
+
+	id := uuid.New().String()
+	user := &models.User{
+		ID:       id,
+		Username: "test_" + id[:8],
+		Email:    "test_" + id[:8] + "@sk8consign.test",
+		Password: "x",
+		FullName: "Test " + role,
+		Role:     role,
+		IsActive: true,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("create test user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.DB.Unscoped().Delete(&models.User{}, "id = ?", id)
+	})
+	return user
+}
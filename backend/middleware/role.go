@@ -3,18 +3,20 @@ package middleware
 import (
 	"encoding/json"
 	"net/http"
+	"sk8consign-backend/rbac"
 )
 
-func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+// RequirePermission - route hanya untuk user yang role-nya memiliki permission
+func RequirePermission(permission rbac.Permission, next http.HandlerFunc) http.HandlerFunc {
 	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		role := r.Header.Get("X-Role")
+		role := Role(r.Context())
 
-		if role != "admin" {
+		if !rbac.Can(role, permission) {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusForbidden)
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"success": false,
-				"message": "Access denied. Admin role required",
+				"message": "Access denied. Missing permission " + string(permission),
 			})
 			return
 		}
@@ -23,9 +25,8 @@ func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
-func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
-	return AuthMiddleware(next)
+// Public - route tanpa login; dipakai supaya setiap route di setupRoutes
+// mendeklarasikan aksesnya secara eksplisit
+func Public(next http.HandlerFunc) http.HandlerFunc {
+	return next
 }
-
-
-
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable - audit log hanya boleh ditambah, tidak boleh diubah / dihapus
+var ErrAuditLogImmutable = errors.New("audit log is append-only")
+
+// AuditLog model - jejak aksi sensitif (append-only, tidak pernah di-update)
+type AuditLog struct {
+	ID         string                 `gorm:"type:char(36);primaryKey" json:"id"`
+	ActorID    *string                `gorm:"type:char(36);index" json:"actor_id"` // nil = system
+	ActorRole  string                 `gorm:"type:varchar(20)" json:"actor_role"`
+	Action     string                 `gorm:"type:varchar(50);not null;index" json:"action"` // mis. user.suspend
+	TargetType string                 `gorm:"type:varchar(30);not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string                 `gorm:"type:char(36);index:idx_audit_target" json:"target_id"`
+	Before     map[string]interface{} `gorm:"type:text;serializer:json" json:"before,omitempty"` // hanya field yang berubah
+	After      map[string]interface{} `gorm:"type:text;serializer:json" json:"after,omitempty"`
+	Details    map[string]interface{} `gorm:"type:text;serializer:json" json:"details"`
+	IPAddress  string                 `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent  string                 `gorm:"type:varchar(255)" json:"user_agent"`
+	CreatedAt  time.Time              `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeUpdate - tolak update lewat GORM
+func (AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete - tolak delete lewat GORM
+func (AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
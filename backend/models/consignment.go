@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommissionTier - satu tingkat fee untuk komisi bertingkat.
+// UpTo = batas atas harga jual (0 berarti tanpa batas), Rate dalam persen.
+type CommissionTier struct {
+	UpTo float64 `json:"up_to"`
+	Rate float64 `json:"rate"`
+}
+
+// ConsignmentAgreement model - perjanjian titip jual antara platform dan consignor
+type ConsignmentAgreement struct {
+	ID              string           `gorm:"type:char(36);primaryKey" json:"id"`
+	ProductID       string           `gorm:"type:char(36);not null;index" json:"product_id"`
+	ConsignorID     string           `gorm:"type:char(36);not null;index" json:"consignor_id"`
+	CommissionType  string           `gorm:"type:varchar(20);not null;default:'percentage'" json:"commission_type"` // percentage, tiered
+	CommissionRate  float64          `gorm:"type:decimal(5,2);default:0" json:"commission_rate"`
+	CommissionTiers []CommissionTier `gorm:"type:text;serializer:json" json:"commission_tiers"`
+	FloorPrice      float64          `gorm:"type:decimal(12,2);default:0" json:"floor_price"`
+	ExpiresAt       *time.Time       `gorm:"index" json:"expires_at"`
+	Status          string           `gorm:"type:varchar(20);default:'active';index" json:"status"` // active, terminated
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
+
+	Product   Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Consignor User    `gorm:"foreignKey:ConsignorID" json:"consignor,omitempty"`
+}
+
+func (ConsignmentAgreement) TableName() string {
+	return "consignment_agreements"
+}
+
+// IsExpired - cek apakah perjanjian sudah lewat tanggal expiry
+func (a *ConsignmentAgreement) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !a.ExpiresAt.After(now)
+}
+
+// ConsignorPayout model - baris payout ledger, satu per OrderItem yang terjual
+type ConsignorPayout struct {
+	ID               string         `gorm:"type:char(36);primaryKey" json:"id"`
+	ConsignorID      string         `gorm:"type:char(36);not null;index" json:"consignor_id"`
+	AgreementID      *string        `gorm:"type:char(36);index" json:"agreement_id"`
+	OrderID          string         `gorm:"type:char(36);not null;index" json:"order_id"`
+	OrderItemID      string         `gorm:"type:char(36);not null;uniqueIndex" json:"order_item_id"`
+	ProductID        string         `gorm:"type:char(36);not null;index" json:"product_id"`
+	GrossAmount      float64        `gorm:"type:decimal(12,2);not null" json:"gross_amount"`
+	CommissionAmount float64        `gorm:"type:decimal(12,2);not null" json:"commission_amount"`
+	PayoutAmount     float64        `gorm:"type:decimal(12,2);not null" json:"payout_amount"`
+	RefundedAmount   float64        `gorm:"type:decimal(12,2);not null;default:0" json:"refunded_amount"` // bagian consignor yang dibalik karena refund
+	Status           string         `gorm:"type:varchar(20);default:'pending';index" json:"status"`       // pending, paid, reversed
+	PaidAt           *time.Time     `json:"paid_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (ConsignorPayout) TableName() string {
+	return "consignor_payouts"
+}
+
+type ConsignmentAgreementResponse struct {
+	ID              string           `json:"id"`
+	ProductID       string           `json:"product_id"`
+	ProductName     string           `json:"product_name,omitempty"`
+	ConsignorID     string           `json:"consignor_id"`
+	CommissionType  string           `json:"commission_type"`
+	CommissionRate  float64          `json:"commission_rate"`
+	CommissionTiers []CommissionTier `json:"commission_tiers,omitempty"`
+	FloorPrice      float64          `json:"floor_price"`
+	ExpiresAt       *time.Time       `json:"expires_at"`
+	Status          string           `json:"status"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+func (a *ConsignmentAgreement) ToResponse() ConsignmentAgreementResponse {
+	return ConsignmentAgreementResponse{
+		ID:              a.ID,
+		ProductID:       a.ProductID,
+		ProductName:     a.Product.Name,
+		ConsignorID:     a.ConsignorID,
+		CommissionType:  a.CommissionType,
+		CommissionRate:  a.CommissionRate,
+		CommissionTiers: a.CommissionTiers,
+		FloorPrice:      a.FloorPrice,
+		ExpiresAt:       a.ExpiresAt,
+		Status:          a.Status,
+		CreatedAt:       a.CreatedAt,
+	}
+}
+
+type ConsignorPayoutResponse struct {
+	ID               string     `json:"id"`
+	OrderID          string     `json:"order_id"`
+	OrderItemID      string     `json:"order_item_id"`
+	ProductID        string     `json:"product_id"`
+	ProductName      string     `json:"product_name,omitempty"`
+	GrossAmount      float64    `json:"gross_amount"`
+	CommissionAmount float64    `json:"commission_amount"`
+	PayoutAmount     float64    `json:"payout_amount"`
+	RefundedAmount   float64    `json:"refunded_amount"`
+	Status           string     `json:"status"`
+	PaidAt           *time.Time `json:"paid_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func (p *ConsignorPayout) ToResponse() ConsignorPayoutResponse {
+	return ConsignorPayoutResponse{
+		ID:               p.ID,
+		OrderID:          p.OrderID,
+		OrderItemID:      p.OrderItemID,
+		ProductID:        p.ProductID,
+		ProductName:      p.Product.Name,
+		GrossAmount:      p.GrossAmount,
+		CommissionAmount: p.CommissionAmount,
+		PayoutAmount:     p.PayoutAmount,
+		RefundedAmount:   p.RefundedAmount,
+		Status:           p.Status,
+		PaidAt:           p.PaidAt,
+		CreatedAt:        p.CreatedAt,
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// LedgerAccount model - chart of accounts untuk double-entry ledger
+type LedgerAccount struct {
+	Code          string    `gorm:"type:varchar(50);primaryKey" json:"code"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type          string    `gorm:"type:varchar(20);not null" json:"type"`           // asset, liability, revenue, contra_revenue
+	NormalBalance string    `gorm:"type:varchar(10);not null" json:"normal_balance"` // debit, credit
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (LedgerAccount) TableName() string {
+	return "ledger_accounts"
+}
+
+// JournalEntry model - satu jurnal yang selalu balance (total debit = total credit).
+// Jurnal bersifat append-only; koreksi dilakukan dengan jurnal pembalik.
+type JournalEntry struct {
+	ID            string    `gorm:"type:char(36);primaryKey" json:"id"`
+	EntryType     string    `gorm:"type:varchar(20);not null;index" json:"entry_type"` // payment, commission, payout, refund
+	ReferenceType string    `gorm:"type:varchar(30);index:idx_journal_reference" json:"reference_type"`
+	ReferenceID   string    `gorm:"type:char(36);index:idx_journal_reference" json:"reference_id"`
+	Description   string    `gorm:"type:varchar(255)" json:"description"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+
+	Lines []JournalLine `gorm:"foreignKey:EntryID" json:"lines,omitempty"`
+}
+
+func (JournalEntry) TableName() string {
+	return "journal_entries"
+}
+
+// JournalLine model - satu sisi debit atau credit dari jurnal
+type JournalLine struct {
+	ID          string    `gorm:"type:char(36);primaryKey" json:"id"`
+	EntryID     string    `gorm:"type:char(36);not null;index" json:"entry_id"`
+	AccountCode string    `gorm:"type:varchar(50);not null;index" json:"account_code"`
+	PartyID     *string   `gorm:"type:char(36);index" json:"party_id"` // buyer / consignor terkait (opsional)
+	Debit       float64   `gorm:"type:decimal(14,2);not null;default:0" json:"debit"`
+	Credit      float64   `gorm:"type:decimal(14,2);not null;default:0" json:"credit"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (JournalLine) TableName() string {
+	return "journal_lines"
+}
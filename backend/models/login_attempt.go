@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginAttempt model - hitungan login gagal berturut-turut per key
+// ("user:<username>" atau "ip:<alamat>")
+type LoginAttempt struct {
+	Key           string    `gorm:"type:varchar(191);primaryKey" json:"key"`
+	Failures      int       `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time `gorm:"not null" json:"last_failure_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
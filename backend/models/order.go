@@ -12,15 +12,17 @@ type Order struct {
 	TotalAmount   float64        `gorm:"type:decimal(12,2);not null" json:"total_amount"`
 	Status        string         `gorm:"type:varchar(20);default:'pending';index" json:"status"`
 	PaymentMethod string         `gorm:"type:varchar(50)" json:"payment_method"`
-	PaymentStatus string         `gorm:"type:varchar(20);default:'pending'" json:"payment_status"`
+	PaymentStatus string         `gorm:"type:varchar(20);default:'pending'" json:"payment_status"` // pending, paid, partially_refunded, refunded
 	ShippingAddr  string         `gorm:"type:text" json:"shipping_address"`
 	Notes         string         `gorm:"type:text" json:"notes"`
+	PaymentDueAt  *time.Time     `json:"payment_due_at"` // batas bayar sebelum reservasi produk dilepas
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
-	User       User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	User          User                 `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	OrderItems    []OrderItem          `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	StatusHistory []OrderStatusHistory `gorm:"foreignKey:OrderID" json:"status_history,omitempty"`
 }
 
 func (Order) TableName() string {
@@ -28,12 +30,22 @@ func (Order) TableName() string {
 }
 
 type OrderItem struct {
-	ID        string         `gorm:"type:char(36);primaryKey" json:"id"`
-	OrderID   string         `gorm:"type:char(36);not null;index" json:"order_id"`
-	ProductID string         `gorm:"type:char(36);not null;index" json:"product_id"`
-	Quantity  int            `gorm:"not null" json:"quantity"`
-	Price     float64        `gorm:"type:decimal(12,2);not null" json:"price"`
-	Subtotal  float64        `gorm:"type:decimal(12,2);not null" json:"subtotal"`
+	ID        string  `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID   string  `gorm:"type:char(36);not null;index" json:"order_id"`
+	ProductID string  `gorm:"type:char(36);not null;index" json:"product_id"`
+	Quantity  int     `gorm:"not null" json:"quantity"`
+	Price     float64 `gorm:"type:decimal(12,2);not null" json:"price"`
+	Subtotal  float64 `gorm:"type:decimal(12,2);not null" json:"subtotal"`
+
+	// Total yang sudah di-refund untuk item ini (partial / full)
+	RefundedAmount float64 `gorm:"type:decimal(12,2);not null;default:0" json:"refunded_amount"`
+
+	// Fulfillment per item, diisi oleh seller pemilik produk
+	FulfillmentStatus string     `gorm:"type:varchar(20);default:'pending';index" json:"fulfillment_status"` // pending, shipped, cancelled
+	Courier           string     `gorm:"type:varchar(50)" json:"courier"`
+	TrackingNumber    string     `gorm:"type:varchar(100)" json:"tracking_number"`
+	ShippedAt         *time.Time `json:"shipped_at"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -45,17 +57,43 @@ func (OrderItem) TableName() string {
 	return "order_items"
 }
 
+// OrderStatusHistory model - jejak setiap transisi status order
+type OrderStatusHistory struct {
+	ID         string    `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID    string    `gorm:"type:char(36);not null;index" json:"order_id"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	ActorID    *string   `gorm:"type:char(36);index" json:"actor_id"`
+	ActorRole  string    `gorm:"type:varchar(20);not null" json:"actor_role"` // buyer, seller, admin, system
+	Note       string    `gorm:"type:varchar(255)" json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
 type OrderResponse struct {
-	ID            string              `json:"id"`
-	UserID        string              `json:"user_id"`
-	TotalAmount   float64             `json:"total_amount"`
-	Status        string              `json:"status"`
-	PaymentMethod string              `json:"payment_method"`
-	PaymentStatus string              `json:"payment_status"`
-	ShippingAddr  string              `json:"shipping_address"`
-	Notes         string              `json:"notes"`
-	OrderItems    []OrderItemResponse `json:"order_items"`
-	CreatedAt     time.Time           `json:"created_at"`
+	ID            string                       `json:"id"`
+	UserID        string                       `json:"user_id"`
+	TotalAmount   float64                      `json:"total_amount"`
+	Status        string                       `json:"status"`
+	PaymentMethod string                       `json:"payment_method"`
+	PaymentStatus string                       `json:"payment_status"`
+	ShippingAddr  string                       `json:"shipping_address"`
+	Notes         string                       `json:"notes"`
+	PaymentDueAt  *time.Time                   `json:"payment_due_at,omitempty"`
+	OrderItems    []OrderItemResponse          `json:"order_items"`
+	StatusHistory []OrderStatusHistoryResponse `json:"status_history,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type OrderItemResponse struct {
@@ -65,6 +103,12 @@ type OrderItemResponse struct {
 	Price     float64         `json:"price"`
 	Subtotal  float64         `json:"subtotal"`
 	Product   ProductResponse `json:"product"`
+
+	RefundedAmount    float64    `json:"refunded_amount"`
+	FulfillmentStatus string     `json:"fulfillment_status"`
+	Courier           string     `json:"courier,omitempty"`
+	TrackingNumber    string     `json:"tracking_number,omitempty"`
+	ShippedAt         *time.Time `json:"shipped_at,omitempty"`
 }
 
 func (o *Order) ToResponse() OrderResponse {
@@ -77,9 +121,26 @@ func (o *Order) ToResponse() OrderResponse {
 			Price:     item.Price,
 			Subtotal:  item.Subtotal,
 			Product:   item.Product.ToResponse(),
+
+			RefundedAmount:    item.RefundedAmount,
+			FulfillmentStatus: item.FulfillmentStatus,
+			Courier:           item.Courier,
+			TrackingNumber:    item.TrackingNumber,
+			ShippedAt:         item.ShippedAt,
 		}
 	}
 
+	var history []OrderStatusHistoryResponse
+	for _, h := range o.StatusHistory {
+		history = append(history, OrderStatusHistoryResponse{
+			FromStatus: h.FromStatus,
+			ToStatus:   h.ToStatus,
+			ActorRole:  h.ActorRole,
+			Note:       h.Note,
+			CreatedAt:  h.CreatedAt,
+		})
+	}
+
 	return OrderResponse{
 		ID:            o.ID,
 		UserID:        o.UserID,
@@ -89,10 +150,23 @@ func (o *Order) ToResponse() OrderResponse {
 		PaymentStatus: o.PaymentStatus,
 		ShippingAddr:  o.ShippingAddr,
 		Notes:         o.Notes,
+		PaymentDueAt:  o.PaymentDueAt,
 		OrderItems:    items,
+		StatusHistory: history,
 		CreatedAt:     o.CreatedAt,
 	}
 }
 
+// ToSellerResponse - response untuk seller: OrderItems sudah difilter ke produk
+// milik seller, dan total hanya menjumlahkan item tersebut.
+func (o *Order) ToSellerResponse() OrderResponse {
+	response := o.ToResponse()
 
+	var total float64
+	for _, item := range o.OrderItems {
+		total += item.Subtotal
+	}
+	response.TotalAmount = total
 
+	return response
+}
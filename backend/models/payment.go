@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentIntent model - satu percobaan pembayaran order di payment provider
+type PaymentIntent struct {
+	ID          string         `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID     string         `gorm:"type:char(36);not null;index" json:"order_id"`
+	UserID      string         `gorm:"type:char(36);not null;index" json:"user_id"`
+	Provider    string         `gorm:"type:varchar(30);not null" json:"provider"`
+	Reference   string         `gorm:"type:varchar(100);not null;uniqueIndex" json:"reference"`
+	Method      string         `gorm:"type:varchar(50)" json:"method"`
+	Amount      float64        `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Status      string         `gorm:"type:varchar(20);default:'pending';index" json:"status"` // pending, paid, failed, expired
+	RedirectURL string         `gorm:"type:varchar(500)" json:"redirect_url"`
+	VANumber    string         `gorm:"type:varchar(50)" json:"va_number"`
+	Bank        string         `gorm:"type:varchar(30)" json:"bank"`
+	ExpiresAt   *time.Time     `json:"expires_at"`
+	PaidAt      *time.Time     `json:"paid_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Pembayaran yang masuk setelah order dibatalkan / sudah dibayar lewat intent lain
+	// dikembalikan otomatis: pending -> refunded
+	RefundStatus    string `gorm:"type:varchar(20);index" json:"refund_status,omitempty"`
+	RefundReference string `gorm:"type:varchar(100)" json:"-"`
+}
+
+func (PaymentIntent) TableName() string {
+	return "payment_intents"
+}
+
+type PaymentIntentResponse struct {
+	ID          string     `json:"id"`
+	OrderID     string     `json:"order_id"`
+	Provider    string     `json:"provider"`
+	Reference   string     `json:"reference"`
+	Method      string     `json:"method"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	RedirectURL string     `json:"redirect_url,omitempty"`
+	VANumber    string     `json:"va_number,omitempty"`
+	Bank        string     `json:"bank,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (p *PaymentIntent) ToResponse() PaymentIntentResponse {
+	return PaymentIntentResponse{
+		ID:          p.ID,
+		OrderID:     p.OrderID,
+		Provider:    p.Provider,
+		Reference:   p.Reference,
+		Method:      p.Method,
+		Amount:      p.Amount,
+		Status:      p.Status,
+		RedirectURL: p.RedirectURL,
+		VANumber:    p.VANumber,
+		Bank:        p.Bank,
+		ExpiresAt:   p.ExpiresAt,
+	}
+}
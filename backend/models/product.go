@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,21 +11,31 @@ import (
 type Product struct {
 	ID          string         `gorm:"type:char(36);primaryKey" json:"id"`
 	UserID      string         `gorm:"type:char(36);not null;index" json:"user_id"`
-	Name        string         `gorm:"type:varchar(255);not null" json:"name"`
-	Description string         `gorm:"type:text" json:"description"`
+	Name        string         `gorm:"type:varchar(255);not null;index:idx_products_name_fulltext,class:FULLTEXT;index:idx_products_fulltext,class:FULLTEXT,priority:1" json:"name"`
+	Description string         `gorm:"type:text;index:idx_products_fulltext,class:FULLTEXT,priority:2" json:"description"`
 	Price       float64        `gorm:"type:decimal(12,2);not null" json:"price"`
 	Category    string         `gorm:"type:varchar(50);index" json:"category"`
-	Condition   string         `gorm:"type:varchar(20)" json:"condition"` // new, like_new, good, fair
-	Status      string         `gorm:"type:varchar(20);default:'available';index" json:"status"` // available, sold, reserved
+	Condition   string         `gorm:"type:varchar(20)" json:"condition"`                        // new, like_new, good, fair
+	Brand       string         `gorm:"type:varchar(100);index" json:"brand"`                     // Element, Independent, Vans, ...
+	Size        string         `gorm:"type:varchar(20);index" json:"size"`                       // lebar deck, ukuran truck / sepatu
+	Status      string         `gorm:"type:varchar(20);default:'available';index" json:"status"` // pending_review, changes_requested, rejected, available, sold, reserved
 	ImageURL    string         `gorm:"type:varchar(500)" json:"image_url"`
 	ViewCount   int            `gorm:"default:0" json:"view_count"`
 	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	Version     int            `gorm:"not null;default:1" json:"version"`    // optimistic locking, naik setiap perubahan status
+	ReviewNote  string         `gorm:"type:varchar(500)" json:"review_note"` // alasan reject / perubahan yang diminta reviewer
+	ReviewedBy  *string        `gorm:"type:char(36)" json:"reviewed_by"`
+	ReviewedAt  *time.Time     `json:"reviewed_at"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relation
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User   User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Images []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+
+	// Highlights - snippet hasil pencarian full-text (tidak disimpan)
+	Highlights map[string]string `gorm:"-" json:"-"`
 }
 
 // TableName override nama tabel
@@ -41,20 +52,42 @@ type ProductResponse struct {
 	Price       float64   `json:"price"`
 	Category    string    `json:"category"`
 	Condition   string    `json:"condition"`
+	Brand       string    `json:"brand"`
+	Size        string    `json:"size"`
 	Status      string    `json:"status"`
 	ImageURL    string    `json:"image_url"`
+	BlurHash    string    `json:"blurhash,omitempty"` // placeholder foto utama
+	ReviewNote  string    `json:"review_note,omitempty"`
 	ViewCount   int       `json:"view_count"`
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-	
+
 	// Seller info
 	SellerName     string `json:"seller_name,omitempty"`
 	SellerUsername string `json:"seller_username,omitempty"`
+
+	// Galeri foto, urut sesuai posisi
+	Images []ProductImageResponse `json:"images"`
+
+	// Snippet pencarian per field (name / description), kata yang cocok dibungkus <mark>
+	Highlights map[string]string `json:"highlights,omitempty"`
 }
 
 // ToResponse convert Product ke ProductResponse
 func (p *Product) ToResponse() ProductResponse {
+	images := make([]ProductImageResponse, 0, len(p.Images))
+	for i := range p.Images {
+		images = append(images, p.Images[i].ToResponse())
+	}
+	var blurHash string
+	for i := range p.Images {
+		if p.Images[i].IsPrimary {
+			blurHash = p.Images[i].BlurHash
+		}
+	}
+	sort.SliceStable(images, func(a, b int) bool { return images[a].Position < images[b].Position })
+
 	return ProductResponse{
 		ID:             p.ID,
 		UserID:         p.UserID,
@@ -63,13 +96,19 @@ func (p *Product) ToResponse() ProductResponse {
 		Price:          p.Price,
 		Category:       p.Category,
 		Condition:      p.Condition,
+		Brand:          p.Brand,
+		Size:           p.Size,
 		Status:         p.Status,
 		ImageURL:       p.ImageURL,
+		BlurHash:       blurHash,
+		ReviewNote:     p.ReviewNote,
 		ViewCount:      p.ViewCount,
 		IsActive:       p.IsActive,
 		CreatedAt:      p.CreatedAt,
 		UpdatedAt:      p.UpdatedAt,
 		SellerName:     p.User.FullName,
 		SellerUsername: p.User.Username,
+		Images:         images,
+		Highlights:     p.Highlights,
 	}
 }
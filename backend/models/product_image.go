@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// ProductImage model - galeri foto produk, urut berdasarkan Position
+type ProductImage struct {
+	ID          string         `gorm:"type:char(36);primaryKey" json:"id"`
+	ProductID   string         `gorm:"type:char(36);not null;index" json:"product_id"`
+	StorageKey  string         `gorm:"type:varchar(500);not null" json:"-"` // key di BlobStore (varian large JPEG)
+	URL         string         `gorm:"type:varchar(500);not null" json:"url"`
+	ContentType string         `gorm:"type:varchar(50)" json:"content_type"`
+	SizeBytes   int64          `json:"size_bytes"` // ukuran file asli yang diunggah
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	BlurHash    string         `gorm:"type:varchar(100)" json:"blurhash"`
+	Variants    []ImageVariant `gorm:"type:text;serializer:json" json:"variants"`
+	Position    int            `gorm:"not null;default:0" json:"position"`
+	IsPrimary   bool           `gorm:"default:false" json:"is_primary"` // foto utama (thumbnail katalog)
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// ImageVariant - satu file hasil proses (ukuran + format) di BlobStore
+type ImageVariant struct {
+	Size      string `json:"size"`   // thumbnail, medium, large
+	Format    string `json:"format"` // jpeg, webp
+	Key       string `json:"key"`
+	URL       string `json:"url"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	SizeBytes int    `json:"size_bytes"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+// ProductImageResponse - satu foto di galeri produk
+type ProductImageResponse struct {
+	ID        string                          `json:"id"`
+	URL       string                          `json:"url"`
+	Width     int                             `json:"width,omitempty"`
+	Height    int                             `json:"height,omitempty"`
+	BlurHash  string                          `json:"blurhash,omitempty"`
+	Variants  map[string]ImageVariantResponse `json:"variants"`
+	Position  int                             `json:"position"`
+	IsPrimary bool                            `json:"is_primary"`
+}
+
+// ImageVariantResponse - URL per format untuk satu ukuran
+type ImageVariantResponse struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	JPEG   string `json:"jpeg,omitempty"`
+	WebP   string `json:"webp,omitempty"`
+}
+
+// ToResponse convert ProductImage ke ProductImageResponse
+func (i *ProductImage) ToResponse() ProductImageResponse {
+	variants := make(map[string]ImageVariantResponse, 3)
+	for _, v := range i.Variants {
+		variant := variants[v.Size]
+		variant.Width, variant.Height = v.Width, v.Height
+		switch v.Format {
+		case "jpeg":
+			variant.JPEG = v.URL
+		case "webp":
+			variant.WebP = v.URL
+		}
+		variants[v.Size] = variant
+	}
+
+	return ProductImageResponse{
+		ID:        i.ID,
+		URL:       i.URL,
+		Width:     i.Width,
+		Height:    i.Height,
+		BlurHash:  i.BlurHash,
+		Variants:  variants,
+		Position:  i.Position,
+		IsPrimary: i.IsPrimary,
+	}
+}
+
+// StorageKeys - semua key blob milik foto ini (varian, atau file tunggal untuk data lama)
+func (i *ProductImage) StorageKeys() []string {
+	if len(i.Variants) == 0 {
+		return []string{i.StorageKey}
+	}
+
+	keys := make([]string, 0, len(i.Variants))
+	for _, v := range i.Variants {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RefreshToken model - refresh token yang disimpan dalam bentuk hash.
+// Setiap refresh merotasi token; token dalam satu login berbagi FamilyID
+// sehingga pemakaian ulang token lama bisa mencabut seluruh family.
+type RefreshToken struct {
+	ID         string     `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID     string     `gorm:"type:char(36);not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"type:char(36);not null;index" json:"family_id"`
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy *string    `gorm:"type:char(36)" json:"replaced_by"`
+	UserAgent  string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReturnRequest model - permintaan retur dari buyer
+type ReturnRequest struct {
+	ID         string         `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID    string         `gorm:"type:char(36);not null;index" json:"order_id"`
+	BuyerID    string         `gorm:"type:char(36);not null;index" json:"buyer_id"`
+	Reason     string         `gorm:"type:text;not null" json:"reason"`
+	PhotoURLs  []string       `gorm:"type:text;serializer:json" json:"photo_urls"`
+	Status     string         `gorm:"type:varchar(20);default:'requested';index" json:"status"` // requested, approved, rejected
+	ReviewerID *string        `gorm:"type:char(36)" json:"reviewer_id"`
+	ReviewNote string         `gorm:"type:text" json:"review_note"`
+	ReviewedAt *time.Time     `json:"reviewed_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Order Order        `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Items []ReturnItem `gorm:"foreignKey:ReturnID" json:"items,omitempty"`
+}
+
+func (ReturnRequest) TableName() string {
+	return "return_requests"
+}
+
+// ReturnItem model - item order yang diretur beserta nominal refund yang diminta
+type ReturnItem struct {
+	ID           string    `gorm:"type:char(36);primaryKey" json:"id"`
+	ReturnID     string    `gorm:"type:char(36);not null;index" json:"return_id"`
+	OrderItemID  string    `gorm:"type:char(36);not null;index" json:"order_item_id"`
+	RefundAmount float64   `gorm:"type:decimal(12,2);not null" json:"refund_amount"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	OrderItem OrderItem `gorm:"foreignKey:OrderItemID" json:"order_item,omitempty"`
+}
+
+func (ReturnItem) TableName() string {
+	return "return_items"
+}
+
+// Refund model - uang yang dikembalikan ke buyer untuk satu OrderItem.
+// Refund lewat gateway dicatat "pending" lalu dikirim ke provider setelah transaksi commit.
+type Refund struct {
+	ID               string     `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID          string     `gorm:"type:char(36);not null;index" json:"order_id"`
+	OrderItemID      string     `gorm:"type:char(36);not null;index" json:"order_item_id"`
+	Sequence         int        `gorm:"not null;default:1" json:"sequence"` // urutan refund untuk OrderItem yang sama
+	ReturnID         *string    `gorm:"type:char(36);index" json:"return_id"`
+	Amount           float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	ConsignorShare   float64    `gorm:"type:decimal(12,2);not null" json:"consignor_share"`
+	CommissionShare  float64    `gorm:"type:decimal(12,2);not null" json:"commission_share"`
+	Reason           string     `gorm:"type:varchar(255)" json:"reason"`
+	ProcessedBy      *string    `gorm:"type:char(36)" json:"processed_by"`
+	Status           string     `gorm:"type:varchar(20);default:'completed';index" json:"status"` // pending, completed
+	Provider         string     `gorm:"type:varchar(50)" json:"provider,omitempty"`
+	PaymentReference string     `gorm:"type:varchar(100)" json:"-"`            // reference payment intent yang di-refund
+	IdempotencyKey   string     `gorm:"type:varchar(64);index" json:"-"`       // order_item_id + sequence
+	ProviderRef      string     `gorm:"type:varchar(100)" json:"provider_ref"` // reference refund dari payment provider
+	CompletedAt      *time.Time `json:"completed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+type ReturnItemResponse struct {
+	OrderItemID  string  `json:"order_item_id"`
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	RefundAmount float64 `json:"refund_amount"`
+}
+
+type ReturnRequestResponse struct {
+	ID         string               `json:"id"`
+	OrderID    string               `json:"order_id"`
+	BuyerID    string               `json:"buyer_id"`
+	Reason     string               `json:"reason"`
+	PhotoURLs  []string             `json:"photo_urls"`
+	Status     string               `json:"status"`
+	ReviewNote string               `json:"review_note,omitempty"`
+	ReviewedAt *time.Time           `json:"reviewed_at,omitempty"`
+	Items      []ReturnItemResponse `json:"items"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+func (r *ReturnRequest) ToResponse() ReturnRequestResponse {
+	items := make([]ReturnItemResponse, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = ReturnItemResponse{
+			OrderItemID:  item.OrderItemID,
+			ProductID:    item.OrderItem.ProductID,
+			ProductName:  item.OrderItem.Product.Name,
+			RefundAmount: item.RefundAmount,
+		}
+	}
+
+	return ReturnRequestResponse{
+		ID:         r.ID,
+		OrderID:    r.OrderID,
+		BuyerID:    r.BuyerID,
+		Reason:     r.Reason,
+		PhotoURLs:  r.PhotoURLs,
+		Status:     r.Status,
+		ReviewNote: r.ReviewNote,
+		ReviewedAt: r.ReviewedAt,
+		Items:      items,
+		CreatedAt:  r.CreatedAt,
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Reservation model - hold produk untuk order yang belum dibayar
+type Reservation struct {
+	ID         string     `gorm:"type:char(36);primaryKey" json:"id"`
+	OrderID    string     `gorm:"type:char(36);not null;index" json:"order_id"`
+	ProductID  string     `gorm:"type:char(36);not null;index" json:"product_id"`
+	UserID     string     `gorm:"type:char(36);not null;index" json:"user_id"`
+	Status     string     `gorm:"type:varchar(20);default:'active';index:idx_reservation_status_expiry" json:"status"` // active, converted, released
+	ExpiresAt  time.Time  `gorm:"not null;index:idx_reservation_status_expiry" json:"expires_at"`
+	ReleasedAt *time.Time `json:"released_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (Reservation) TableName() string {
+	return "reservations"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RecoveryCode model - kode cadangan 2FA sekali pakai (hash SHA-256)
+type RecoveryCode struct {
+	ID        string     `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID    string     `gorm:"type:char(36);not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null;index" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
+
+// TwoFactorPolicy model - role yang wajib memakai 2FA
+type TwoFactorPolicy struct {
+	Role      string    `gorm:"type:varchar(20);primaryKey" json:"role"`
+	Required  bool      `gorm:"not null;default:false" json:"required"`
+	UpdatedBy *string   `gorm:"type:char(36)" json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (TwoFactorPolicy) TableName() string {
+	return "two_factor_policies"
+}
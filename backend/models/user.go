@@ -14,11 +14,30 @@ type User struct {
 	Password  string         `gorm:"type:varchar(255);not null" json:"-"` // tidak di-return ke client
 	FullName  string         `gorm:"type:varchar(100)" json:"full_name"`
 	Phone     string         `gorm:"type:varchar(20)" json:"phone"`
-	Role      string         `gorm:"type:varchar(20);default:'user'" json:"role"` // user, admin
+	Role      string         `gorm:"type:varchar(20);default:'buyer'" json:"role"` // lihat rbac.Roles()
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // soft delete
+
+	// Token yang diterbitkan sebelum waktu ini ditolak (ganti password, ganti role, nonaktif, logout semua device)
+	TokensRevokedAt *time.Time `json:"-"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Akun terkunci sementara karena terlalu banyak login gagal
+	LockedUntil *time.Time `json:"locked_until"`
+
+	// Two-factor authentication (TOTP). Secret disimpan terenkripsi; TwoFactorLastStep
+	// mencegah kode yang sama dipakai dua kali.
+	TwoFactorEnabled   bool       `gorm:"default:false" json:"two_factor_enabled"`
+	TwoFactorSecret    string     `gorm:"type:varchar(255)" json:"-"`
+	TwoFactorLastStep  int64      `gorm:"default:0" json:"-"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at"`
+
+	// Diisi saat admin menangguhkan akun (is_active = false)
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspensionReason string     `gorm:"type:varchar(255)" json:"suspension_reason"`
 }
 
 // TableName override nama tabel
@@ -36,6 +55,13 @@ type UserResponse struct {
 	Role      string    `json:"role"`
 	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
+
+	EmailVerified    bool `json:"email_verified"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
 }
 
 // ToResponse convert User ke UserResponse
@@ -49,5 +75,12 @@ func (u *User) ToResponse() UserResponse {
 		Role:      u.Role,
 		IsActive:  u.IsActive,
 		CreatedAt: u.CreatedAt,
+
+		EmailVerified:    u.EmailVerifiedAt != nil,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+
+		LockedUntil:      u.LockedUntil,
+		SuspendedAt:      u.SuspendedAt,
+		SuspensionReason: u.SuspensionReason,
 	}
 }
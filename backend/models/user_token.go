@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserToken model - token sekali pakai yang dikirim lewat email
+// (verifikasi email, reset password). Yang disimpan hanya hash-nya.
+type UserToken struct {
+	ID        string     `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID    string     `gorm:"type:char(36);not null;index" json:"user_id"`
+	Purpose   string     `gorm:"type:varchar(30);not null;index" json:"purpose"` // email_verification, password_reset
+	TokenHash string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
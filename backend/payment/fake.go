@@ -0,0 +1,79 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeProvider - provider lokal untuk development dan test. Tidak memanggil
+// jaringan; webhook bisa disimulasikan dengan SignedWebhook.
+type FakeProvider struct {
+	WebhookSecret string
+	CheckoutURL   string
+}
+
+// NewFakeProvider - buat fake provider
+func NewFakeProvider(webhookSecret, checkoutURL string) *FakeProvider {
+	return &FakeProvider{WebhookSecret: webhookSecret, CheckoutURL: checkoutURL}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	reference := "FAKE-" + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:12])
+	expiresAt := req.ExpiresAt
+
+	intent := &Intent{
+		Reference: reference,
+		ExpiresAt: &expiresAt,
+	}
+
+	// Tanpa checkout URL semua metode dilayani sebagai virtual account
+	if req.Method == "bank_transfer" || p.CheckoutURL == "" {
+		intent.Bank = "fakebank"
+		intent.VANumber = "8808" + fmt.Sprintf("%012d", time.Now().UnixNano()%1e12)
+	} else {
+		intent.RedirectURL = p.CheckoutURL + "?reference=" + reference
+	}
+
+	return intent, nil
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !VerifySignature(p.WebhookSecret, body, header.Get(SignatureHeader)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	return &event, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (string, error) {
+	return "FAKE-RF-" + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:12]), nil
+}
+
+// SignedWebhook - buat body + header webhook bertanda tangan, seolah dikirim gateway
+func (p *FakeProvider) SignedWebhook(event WebhookEvent) ([]byte, http.Header, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(SignatureHeader, Sign(p.WebhookSecret, body))
+
+	return body, header, nil
+}
@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GatewayProvider - provider REST bergaya Midtrans/Xendit: intent dibuat lewat
+// API gateway (mengembalikan redirect URL atau nomor VA), dan gateway mengirim
+// webhook yang ditandatangani HMAC-SHA256 dengan webhook secret.
+type GatewayProvider struct {
+	BaseURL       string
+	APIKey        string
+	WebhookSecret string
+	CallbackURL   string
+	HTTPClient    *http.Client
+}
+
+// NewGatewayProvider - buat provider gateway dengan HTTP client default
+func NewGatewayProvider(baseURL, apiKey, webhookSecret, callbackURL string) *GatewayProvider {
+	return &GatewayProvider{
+		BaseURL:       strings.TrimRight(baseURL, "/"),
+		APIKey:        apiKey,
+		WebhookSecret: webhookSecret,
+		CallbackURL:   callbackURL,
+		HTTPClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *GatewayProvider) Name() string {
+	return "gateway"
+}
+
+type gatewayIntentRequest struct {
+	ReferenceID string          `json:"reference_id"`
+	Amount      float64         `json:"amount"`
+	Currency    string          `json:"currency"`
+	Method      string          `json:"payment_method,omitempty"`
+	Customer    gatewayCustomer `json:"customer"`
+	CallbackURL string          `json:"callback_url,omitempty"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+}
+
+type gatewayCustomer struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type gatewayIntentResponse struct {
+	ID          string     `json:"id"`
+	RedirectURL string     `json:"redirect_url"`
+	VANumber    string     `json:"va_number"`
+	Bank        string     `json:"bank"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Message     string     `json:"message"`
+}
+
+func (p *GatewayProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	payload, err := json.Marshal(gatewayIntentRequest{
+		ReferenceID: req.OrderID,
+		Amount:      req.Amount,
+		Currency:    "IDR",
+		Method:      req.Method,
+		Customer:    gatewayCustomer{Name: req.CustomerName, Email: req.CustomerEmail},
+		CallbackURL: p.CallbackURL,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/v1/payment-intents", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+
+	resp, err := p.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("payment gateway unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body gatewayIntentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid payment gateway response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("payment gateway error (%d): %s", resp.StatusCode, body.Message)
+	}
+
+	return &Intent{
+		Reference:   body.ID,
+		RedirectURL: body.RedirectURL,
+		VANumber:    body.VANumber,
+		Bank:        body.Bank,
+		ExpiresAt:   body.ExpiresAt,
+	}, nil
+}
+
+func (p *GatewayProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !VerifySignature(p.WebhookSecret, body, header.Get(SignatureHeader)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	return &event, nil
+}
+
+type gatewayRefundRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+type gatewayRefundResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (p *GatewayProvider) Refund(ctx context.Context, req RefundRequest) (string, error) {
+	payload, err := json.Marshal(gatewayRefundRequest{Amount: req.Amount, Reason: req.Reason})
+	if err != nil {
+		return "", err
+	}
+
+	url := p.BaseURL + "/v1/payment-intents/" + req.Reference + "/refunds"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+
+	resp, err := p.HTTPClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("payment gateway unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body gatewayRefundResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid payment gateway response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("payment gateway error (%d): %s", resp.StatusCode, body.Message)
+	}
+
+	return body.ID, nil
+}
@@ -0,0 +1,100 @@
+// Package payment - integrasi payment gateway di balik interface PaymentProvider.
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Status pembayaran yang dilaporkan provider lewat webhook
+const (
+	StatusPending = "pending"
+	StatusPaid    = "paid"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+// SignatureHeader - header berisi HMAC-SHA256 (hex) dari raw body webhook
+const SignatureHeader = "X-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// IntentRequest - data untuk membuat payment intent di provider
+type IntentRequest struct {
+	OrderID        string
+	IdempotencyKey string // unik per percobaan, supaya intent baru tidak tertukar dengan yang lama
+	Amount         float64
+	Method         string // bank_transfer, ewallet, card, ...
+	CustomerName   string
+	CustomerEmail  string
+	ExpiresAt      time.Time
+}
+
+// Intent - instruksi pembayaran dari provider (redirect URL atau nomor VA)
+type Intent struct {
+	Reference   string
+	RedirectURL string
+	VANumber    string
+	Bank        string
+	ExpiresAt   *time.Time
+}
+
+// WebhookEvent - notifikasi pembayaran yang sudah diverifikasi
+type WebhookEvent struct {
+	Reference string  `json:"reference"`
+	OrderID   string  `json:"order_id"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+}
+
+// RefundRequest - pengembalian dana (sebagian / penuh) atas pembayaran yang sudah sukses
+type RefundRequest struct {
+	Reference      string // reference payment intent yang dibayar
+	IdempotencyKey string
+	Amount         float64
+	Reason         string
+}
+
+// PaymentProvider - kontrak untuk setiap payment gateway
+type PaymentProvider interface {
+	// Name - nama provider yang disimpan di payment_intents.provider
+	Name() string
+
+	// CreateIntent - minta instruksi pembayaran untuk order
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+
+	// ParseWebhook - verifikasi signature lalu parse body webhook.
+	// Harus mengembalikan ErrInvalidSignature jika signature tidak cocok.
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+
+	// Refund - kembalikan dana ke buyer, mengembalikan reference refund dari provider
+	Refund(ctx context.Context, req RefundRequest) (string, error)
+}
+
+// Sign - HMAC-SHA256 (hex) dari body dengan secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature - bandingkan signature secara constant-time
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,152 @@
+// Package rbac - role dan permission. Setiap route mendeklarasikan permission
+// yang dibutuhkan; role hanyalah kumpulan permission yang sudah ditentukan di sini.
+package rbac
+
+import "sort"
+
+// Role user
+const (
+	RoleBuyer     = "buyer"
+	RoleConsignor = "consignor"
+	RoleModerator = "moderator"
+	RoleFinance   = "finance"
+	RoleAdmin     = "admin"
+)
+
+// Permission - nama aksi dengan format resource:action
+type Permission string
+
+const (
+	// Akun sendiri: profil, 2FA, notifikasi
+	PermAccountManage Permission = "account:manage"
+
+	// Belanja: keranjang, checkout, bayar, order & retur milik sendiri
+	PermOrderCreate Permission = "order:create"
+	PermOrderRead   Permission = "order:read"
+	PermOrderUpdate Permission = "order:update"
+
+	// Penjual (consignor): order masuk, kirim / batalkan, review retur, pendapatan
+	PermOrderFulfil     Permission = "order:fulfil"
+	PermReturnReview    Permission = "return:review"
+	PermConsignmentRead Permission = "consignment:read"
+
+	// Produk milik sendiri
+	PermProductCreate Permission = "product:create"
+	PermProductUpdate Permission = "product:update"
+	PermProductDelete Permission = "product:delete"
+
+	// Staff
+	PermProductApprove    Permission = "product:approve"
+	PermOrderManage       Permission = "order:manage"
+	PermOrderRefund       Permission = "order:refund"
+	PermReturnManage      Permission = "return:manage"
+	PermConsignmentManage Permission = "consignment:manage"
+	PermPayoutManage      Permission = "payout:manage"
+	PermLedgerRead        Permission = "ledger:read"
+	PermUserRead          Permission = "user:read"
+	PermUserBan           Permission = "user:ban"
+	PermUserUnlock        Permission = "user:unlock"
+	PermRoleAssign        Permission = "role:assign"
+	PermSecurityManage    Permission = "security:manage"
+	PermAuditRead         Permission = "audit:read"
+)
+
+var shopperPermissions = []Permission{
+	PermAccountManage,
+	PermOrderCreate,
+	PermOrderRead,
+	PermOrderUpdate,
+}
+
+var rolePermissions = map[string][]Permission{
+	RoleBuyer: shopperPermissions,
+
+	RoleConsignor: append(append([]Permission{}, shopperPermissions...),
+		PermOrderFulfil,
+		PermReturnReview,
+		PermConsignmentRead,
+		PermProductCreate,
+		PermProductUpdate,
+		PermProductDelete,
+	),
+
+	RoleModerator: {
+		PermAccountManage,
+		PermProductApprove,
+		PermUserRead,
+		PermUserBan,
+		PermUserUnlock,
+	},
+
+	RoleFinance: {
+		PermAccountManage,
+		PermOrderRead,
+		PermOrderRefund,
+		PermReturnReview,
+		PermReturnManage,
+		PermPayoutManage,
+		PermLedgerRead,
+		PermUserRead,
+	},
+
+	RoleAdmin: {
+		PermAccountManage,
+		PermOrderRead,
+		PermOrderUpdate,
+		PermOrderFulfil,
+		PermReturnReview,
+		PermConsignmentRead,
+		PermProductCreate,
+		PermProductUpdate,
+		PermProductDelete,
+		PermProductApprove,
+		PermOrderManage,
+		PermOrderRefund,
+		PermReturnManage,
+		PermConsignmentManage,
+		PermPayoutManage,
+		PermLedgerRead,
+		PermUserRead,
+		PermUserBan,
+		PermUserUnlock,
+		PermRoleAssign,
+		PermSecurityManage,
+		PermAuditRead,
+	},
+}
+
+var roleIndex = buildIndex()
+
+func buildIndex() map[string]map[Permission]bool {
+	index := make(map[string]map[Permission]bool, len(rolePermissions))
+	for role, permissions := range rolePermissions {
+		index[role] = make(map[Permission]bool, len(permissions))
+		for _, permission := range permissions {
+			index[role][permission] = true
+		}
+	}
+	return index
+}
+
+// Can - apakah role memiliki permission
+func Can(role string, permission Permission) bool {
+	return roleIndex[role][permission]
+}
+
+// IsValidRole - role dikenal
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Roles - semua role, urut dari paling sedikit hak aksesnya
+func Roles() []string {
+	return []string{RoleBuyer, RoleConsignor, RoleModerator, RoleFinance, RoleAdmin}
+}
+
+// Permissions - permission milik role, urut alfabet
+func Permissions(role string) []Permission {
+	permissions := append([]Permission{}, rolePermissions[role]...)
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Bobot per field / jenis kecocokan
+const (
+	boostNameExact        = 3.0
+	boostDescriptionExact = 1.0
+	boostNameFuzzy        = 1.5
+	boostDescriptionFuzzy = 0.5
+	boostNamePrefix       = 1.0
+)
+
+// BleveIndex - index embedded (in-memory atau file) untuk development dan
+// pengujian tanpa MySQL. Isinya perlu dibangun ulang saat start (lihat
+// services.ReindexProducts).
+type BleveIndex struct {
+	index bleve.Index
+}
+
+// NewBleveIndex - buka / buat index di path; path kosong = in-memory
+func NewBleveIndex(path string) (*BleveIndex, error) {
+	if path == "" {
+		index, err := bleve.NewMemOnly(productMapping())
+		if err != nil {
+			return nil, err
+		}
+		return &BleveIndex{index: index}, nil
+	}
+
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, productMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &BleveIndex{index: index}, nil
+}
+
+// productMapping - name & description disimpan (untuk highlight) dengan
+// analyzer standard: lowercase tanpa stemming, cocok untuk teks campuran ID / EN
+func productMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = standard.Name
+	text.Store = true
+	text.IncludeTermVectors = true
+
+	product := bleve.NewDocumentMapping()
+	product.AddFieldMappingsAt(FieldName, text)
+	product.AddFieldMappingsAt(FieldDescription, text)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = product
+	indexMapping.DefaultAnalyzer = standard.Name
+	return indexMapping
+}
+
+func (b *BleveIndex) Name() string {
+	return "bleve"
+}
+
+func (b *BleveIndex) Index(ctx context.Context, doc Document) error {
+	return b.index.Index(doc.ID, map[string]interface{}{
+		FieldName:        doc.Name,
+		FieldDescription: doc.Description,
+	})
+}
+
+func (b *BleveIndex) Delete(ctx context.Context, id string) error {
+	return b.index.Delete(id)
+}
+
+// Close - tutup index (flush ke disk untuk index berbasis file)
+func (b *BleveIndex) Close() error {
+	return b.index.Close()
+}
+
+// Search - tiap kata dicari persis, fuzzy (edit distance 1-2), dan sebagai
+// awalan; hasil digabung (OR) dan diurutkan berdasarkan skor
+func (b *BleveIndex) Search(ctx context.Context, text string, limit int) ([]Hit, error) {
+	terms := Terms(text)
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+
+	clauses := make([]query.Query, 0, len(terms)*5)
+	for _, term := range terms {
+		clauses = append(clauses,
+			termQuery(term, FieldName, boostNameExact),
+			termQuery(term, FieldDescription, boostDescriptionExact),
+		)
+
+		if fuzziness := fuzzinessFor(term); fuzziness > 0 {
+			clauses = append(clauses,
+				fuzzyQuery(term, FieldName, fuzziness, boostNameFuzzy),
+				fuzzyQuery(term, FieldDescription, fuzziness, boostDescriptionFuzzy),
+			)
+		}
+
+		if utf8.RuneCountInString(term) >= 3 {
+			prefix := bleve.NewPrefixQuery(term)
+			prefix.SetField(FieldName)
+			prefix.SetBoost(boostNamePrefix)
+			clauses = append(clauses, prefix)
+		}
+	}
+
+	request := bleve.NewSearchRequestOptions(bleve.NewDisjunctionQuery(clauses...), limit, 0, false)
+	request.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	request.Highlight.AddField(FieldName)
+	request.Highlight.AddField(FieldDescription)
+
+	result, err := b.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		highlights := make(map[string]string, len(match.Fragments))
+		for field, fragments := range match.Fragments {
+			// Bleve juga mengembalikan field tanpa kecocokan; hanya ambil yang di-highlight
+			if len(fragments) > 0 && strings.Contains(fragments[0], markOpen) {
+				highlights[field] = strings.TrimSpace(fragments[0])
+			}
+		}
+
+		hits = append(hits, Hit{ID: match.ID, Score: match.Score, Highlights: highlights})
+	}
+
+	return hits, nil
+}
+
+func termQuery(term, field string, boost float64) query.Query {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	q.SetBoost(boost)
+	return q
+}
+
+func fuzzyQuery(term, field string, fuzziness int, boost float64) query.Query {
+	q := bleve.NewFuzzyQuery(term)
+	q.SetField(field)
+	q.SetFuzziness(fuzziness)
+	q.SetBoost(boost)
+	return q
+}
+
+// fuzzinessFor - edit distance yang ditoleransi: kata pendek harus persis
+func fuzzinessFor(term string) int {
+	switch length := utf8.RuneCountInString(term); {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newTestBleveIndex(t *testing.T, docs ...Document) *BleveIndex {
+	t.Helper()
+
+	index, err := NewBleveIndex("")
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	t.Cleanup(func() { index.Close() })
+
+	for _, doc := range docs {
+		if err := index.Index(context.Background(), doc); err != nil {
+			t.Fatalf("Index(%s): %v", doc.ID, err)
+		}
+	}
+	return index
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestBleveSearch(t *testing.T) {
+	index := newTestBleveIndex(t,
+		Document{ID: "name", Name: "Skateboard Deck Element 8.0", Description: "Maple 7 ply, <b>grip</b> included"},
+		Document{ID: "description", Name: "Element Grip Tape", Description: "Fits any skateboard deck"},
+		Document{ID: "wheels", Name: "Spitfire Wheels 52mm", Description: "Formula Four urethane"},
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // urutan hasil yang diharapkan
+	}{
+		{"name weighted above description", "skateboard", []string{"name", "description"}},
+		{"typo tolerance", "skatebaord", []string{"name", "description"}},
+		{"prefix while typing", "spitf", []string{"wheels"}},
+		{"short terms must match exactly", "ply", []string{"name"}},
+		{"no match", "bearings", []string{}},
+		{"only punctuation", "***", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := index.Search(context.Background(), tt.query, 10)
+			if err != nil {
+				t.Fatalf("Search(%q): %v", tt.query, err)
+			}
+
+			got := hitIDs(hits)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBleveSearchHighlights(t *testing.T) {
+	index := newTestBleveIndex(t,
+		Document{ID: "1", Name: "Skateboard Deck", Description: `<b>Deck</b> & "grip"`},
+	)
+
+	hits, err := index.Search(context.Background(), "deck", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+
+	name := hits[0].Highlights[FieldName]
+	if !strings.Contains(name, "<mark>Deck</mark>") {
+		t.Errorf("name highlight = %q, want the match wrapped in <mark>", name)
+	}
+
+	description := hits[0].Highlights[FieldDescription]
+	if strings.Contains(description, "<b>") || !strings.Contains(description, "&lt;b&gt;") {
+		t.Errorf("description highlight = %q, want stored HTML escaped", description)
+	}
+}
+
+func TestBleveDelete(t *testing.T) {
+	index := newTestBleveIndex(t, Document{ID: "1", Name: "Skateboard Deck"})
+
+	if err := index.Delete(context.Background(), "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	hits, err := index.Search(context.Background(), "deck", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("got %v after delete, want no hits", hitIDs(hits))
+	}
+}
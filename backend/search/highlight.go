@@ -0,0 +1,117 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+
+	// snippetContext - byte sebelum kata pertama yang cocok
+	snippetContext = 40
+	ellipsis       = "…"
+)
+
+// Highlight - cuplikan text sepanjang ~maxLen byte di sekitar kata pertama yang
+// cocok dengan terms (awalan kata, termasuk awalan toleransi salah ketik). Hasil
+// sudah di-escape HTML; kosong jika tidak ada kata yang cocok.
+func Highlight(text string, terms []string, maxLen int) string {
+	patterns := make([]string, 0, len(terms)*2)
+	for _, term := range terms {
+		patterns = append(patterns, term)
+		if prefix := fuzzyPrefix(term); prefix != "" {
+			patterns = append(patterns, prefix)
+		}
+	}
+
+	type span struct{ start, end int }
+	var matches []span
+
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if !isWordRune(r) {
+			start += size
+			continue
+		}
+
+		end := start
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += size
+		}
+
+		word := strings.ToLower(text[start:end])
+		for _, pattern := range patterns {
+			if strings.HasPrefix(word, pattern) {
+				matches = append(matches, span{start, end})
+				break
+			}
+		}
+		start = end
+	}
+
+	if len(matches) == 0 {
+		return ""
+	}
+
+	// Jendela cuplikan, dipotong di batas spasi supaya tidak memotong kata
+	from := 0
+	if matches[0].start > snippetContext {
+		from = matches[0].start - snippetContext
+		for !utf8.RuneStart(text[from]) {
+			from++
+		}
+		if space := strings.IndexByte(text[from:matches[0].start], ' '); space >= 0 {
+			from += space + 1
+		}
+	}
+	to := len(text)
+	if maxLen > 0 && to-from > maxLen {
+		to = from + maxLen
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to--
+		}
+		if to < matches[0].end {
+			to = matches[0].end
+		}
+		if space := strings.LastIndexByte(text[matches[0].end:to], ' '); space >= 0 {
+			to = matches[0].end + space
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString(ellipsis)
+	}
+	cursor := from
+	for _, match := range matches {
+		if match.start < from {
+			continue
+		}
+		if match.end > to {
+			break
+		}
+		b.WriteString(html.EscapeString(text[cursor:match.start]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[match.start:match.end]))
+		b.WriteString(markClose)
+		cursor = match.end
+	}
+	b.WriteString(html.EscapeString(text[cursor:to]))
+	if to < len(text) {
+		b.WriteString(ellipsis)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
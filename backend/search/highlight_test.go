@@ -0,0 +1,72 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		query  string
+		maxLen int
+		want   string
+	}{
+		{
+			name:  "exact match",
+			text:  "Element skateboard deck",
+			query: "deck",
+			want:  "Element skateboard <mark>deck</mark>",
+		},
+		{
+			name:  "typo matched through fuzzy prefix",
+			text:  "Pro skateboard deck 8.0",
+			query: "skatebaord",
+			want:  "Pro <mark>skateboard</mark> deck 8.0",
+		},
+		{
+			name:  "match is case insensitive and keeps original case",
+			text:  "SKATEBOARD Deck",
+			query: "skateboard deck",
+			want:  "<mark>SKATEBOARD</mark> <mark>Deck</mark>",
+		},
+		{
+			name:  "html is escaped around and inside marks",
+			text:  `<b>Deck</b> & "grip" <script>alert(1)</script>`,
+			query: "deck script",
+			want:  "&lt;b&gt;<mark>Deck</mark>&lt;/b&gt; &amp; &#34;grip&#34; &lt;<mark>script</mark>&gt;alert(1)&lt;/<mark>script</mark>&gt;",
+		},
+		{
+			name:  "no match",
+			text:  "Independent trucks",
+			query: "wheels",
+			want:  "",
+		},
+		{
+			name:   "long text is cut around the first match",
+			text:   "Lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor deck incididunt ut labore et dolore magna aliqua",
+			query:  "deck",
+			maxLen: 60,
+			want:   "…adipiscing elit sed do eiusmod tempor <mark>deck</mark> incididunt ut…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, Terms(tt.query), tt.maxLen); got != tt.want {
+				t.Errorf("Highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightNeverEmitsRawMarkup(t *testing.T) {
+	text := `<img src=x onerror="alert('deck')"> deck`
+	got := Highlight(text, Terms("deck"), 0)
+
+	stripped := strings.NewReplacer(markOpen, "", markClose, "").Replace(got)
+	if strings.ContainsAny(stripped, `<>"'`) {
+		t.Errorf("Highlight() left unescaped markup: %q", got)
+	}
+}
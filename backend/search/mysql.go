@@ -0,0 +1,100 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Panjang maksimum snippet per field
+const (
+	nameSnippetLength        = 255
+	descriptionSnippetLength = 160
+)
+
+// MySQLIndex - pencarian langsung ke tabel products lewat index FULLTEXT
+// (idx_products_fulltext dan idx_products_name_fulltext, dibuat AutoMigrate).
+// InnoDB memperbarui index sendiri, jadi Index / Delete tidak melakukan apa-apa.
+type MySQLIndex struct {
+	DB *gorm.DB
+}
+
+// NewMySQLIndex - buat index FULLTEXT di atas koneksi database
+func NewMySQLIndex(db *gorm.DB) *MySQLIndex {
+	return &MySQLIndex{DB: db}
+}
+
+func (m *MySQLIndex) Name() string {
+	return "mysql"
+}
+
+func (m *MySQLIndex) Index(ctx context.Context, doc Document) error {
+	return nil
+}
+
+func (m *MySQLIndex) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+// Search - BOOLEAN MODE dengan wildcard awalan. Kecocokan di nama diberi bobot
+// dua kali lipat; awalan toleransi salah ketik ikut dicari dengan bobot lebih rendah.
+func (m *MySQLIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	terms := Terms(query)
+	booleanQuery := booleanModeQuery(terms)
+	if booleanQuery == "" {
+		return []Hit{}, nil
+	}
+
+	var rows []struct {
+		ID          string
+		Name        string
+		Description string
+		Score       float64
+	}
+
+	err := m.DB.WithContext(ctx).
+		Table("products").
+		Select(
+			"id, name, description, "+
+				"MATCH(name) AGAINST (? IN BOOLEAN MODE) * 2 + MATCH(name, description) AGAINST (? IN BOOLEAN MODE) AS score",
+			booleanQuery, booleanQuery,
+		).
+		Where("deleted_at IS NULL").
+		Where("MATCH(name, description) AGAINST (? IN BOOLEAN MODE)", booleanQuery).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		highlights := make(map[string]string, 2)
+		if snippet := Highlight(row.Name, terms, nameSnippetLength); snippet != "" {
+			highlights[FieldName] = snippet
+		}
+		if snippet := Highlight(row.Description, terms, descriptionSnippetLength); snippet != "" {
+			highlights[FieldDescription] = snippet
+		}
+
+		hits = append(hits, Hit{ID: row.ID, Score: row.Score, Highlights: highlights})
+	}
+
+	return hits, nil
+}
+
+// booleanModeQuery - "skatebaord deck" -> "(>skatebaord* <skateb*) deck*".
+// Operator BOOLEAN MODE tidak bisa masuk karena Terms hanya menyisakan huruf / angka.
+func booleanModeQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if prefix := fuzzyPrefix(term); prefix != "" {
+			parts = append(parts, "(>"+term+"* <"+prefix+"*)")
+		} else {
+			parts = append(parts, term+"*")
+		}
+	}
+	return strings.Join(parts, " ")
+}
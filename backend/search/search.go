@@ -0,0 +1,77 @@
+// Package search - index full-text produk dengan ranking relevansi, toleransi
+// salah ketik, dan cuplikan (snippet) yang di-highlight.
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Field yang diindeks dan di-highlight
+const (
+	FieldName        = "name"
+	FieldDescription = "description"
+)
+
+// maxQueryTerms - kata yang diproses dari satu query; sisanya diabaikan
+const maxQueryTerms = 10
+
+// Document - data produk yang diindeks
+type Document struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// Hit - satu hasil pencarian, urut dari skor tertinggi
+type Hit struct {
+	ID    string
+	Score float64
+	// Highlights - field -> snippet HTML; teks sudah di-escape dan kata yang
+	// cocok dibungkus <mark>...</mark>
+	Highlights map[string]string
+}
+
+// SearchIndex - backend pencarian produk. Index hanya menyimpan teks; filter
+// lain (status, kategori, harga) tetap dikerjakan database.
+type SearchIndex interface {
+	Name() string
+	Index(ctx context.Context, doc Document) error
+	Delete(ctx context.Context, id string) error
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+}
+
+// Terms - pecah query jadi kata (huruf / angka) lowercase tanpa duplikat
+func Terms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxQueryTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// fuzzyPrefix - awalan kata yang dipakai untuk toleransi salah ketik di bagian
+// belakang kata ("skatebaord" -> "skateb"); kosong untuk kata pendek
+func fuzzyPrefix(term string) string {
+	length := utf8.RuneCountInString(term)
+	if length < 5 {
+		return ""
+	}
+
+	keep := max(3, length*2/3)
+	return string([]rune(term)[:keep])
+}
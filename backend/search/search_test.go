@@ -0,0 +1,71 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"lowercase and split", "Skateboard DECK", []string{"skateboard", "deck"}},
+		{"punctuation is a separator", "deck,8.0-inch!", []string{"deck", "8", "0", "inch"}},
+		{"duplicates removed", "deck Deck DECK", []string{"deck"}},
+		{"boolean operators dropped", `+deck -"wheels" >bearing* <(truck)~`, []string{"deck", "wheels", "bearing", "truck"}},
+		{"unicode letters kept", "Sepatu Ñike ünïcode", []string{"sepatu", "ñike", "ünïcode"}},
+		{"empty", "  ,.- ", []string{}},
+		{"capped at maxQueryTerms", "a b c d e f g h i j k l", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Terms(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Terms(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyPrefix(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"deck", ""},
+		{"wheel", "whe"},
+		{"bearing", "bear"},
+		{"skatebaord", "skateb"},
+		{"skateboard", "skateb"},
+		{"ñikeñike", "ñikeñ"},
+	}
+
+	for _, tt := range tests {
+		if got := fuzzyPrefix(tt.term); got != tt.want {
+			t.Errorf("fuzzyPrefix(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestBooleanModeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"typo keeps a weaker prefix", "skatebaord deck", "(>skatebaord* <skateb*) deck*"},
+		{"short terms are plain prefixes", "abec 7", "abec* 7*"},
+		{"operators from user input are stripped", `+deck -"grip" @tape`, "deck* grip* tape*"},
+		{"empty query", "!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := booleanModeQuery(Terms(tt.query)); got != tt.want {
+				t.Errorf("booleanModeQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
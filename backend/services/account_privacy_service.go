@@ -0,0 +1,318 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TokenPurposeAccountDeletion = "account_deletion"
+
+	accountDeletionTTL = time.Hour
+)
+
+// Aksi audit untuk permintaan privasi
+const (
+	AuditUserDelete = "user.delete"
+	AuditUserExport = "user.export"
+)
+
+// Order yang masih berjalan menahan penghapusan akun (buyer atau seller)
+var openOrderStatuses = []string{OrderStatusPending, OrderStatusConfirmed, OrderStatusShipped, OrderStatusDelivered}
+
+// RequestAccountDeletion - langkah pertama hapus akun: cek password (dan kode 2FA
+// jika aktif), lalu kirim kode konfirmasi ke email user
+func RequestAccountDeletion(userID, password, code string) error {
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	if !utils.CheckPassword(user.Password, password) {
+		return errors.New("password is incorrect")
+	}
+
+	var token string
+	usedRecoveryCode := false
+	err := database.DB.Transaction(func(tx *gorm.DB) (err error) {
+		if err := checkAccountDeletable(tx, &user); err != nil {
+			return err
+		}
+
+		if err := checkResendInterval(tx, user.ID, TokenPurposeAccountDeletion); err != nil {
+			return err
+		}
+
+		// Kode 2FA dipakai paling akhir dan di transaksi yang sama: recovery code tidak
+		// hangus jika permintaan ditolak oleh pengecekan di atas
+		if user.TwoFactorEnabled {
+			if usedRecoveryCode, err = verifySecondFactor(tx, &user, code); err != nil {
+				return err
+			}
+		}
+
+		token, err = issueUserToken(tx, user.ID, TokenPurposeAccountDeletion, accountDeletionTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if usedRecoveryCode {
+		notifyRecoveryCodeUsed(user.ID)
+	}
+
+	return sendMail(user.Email, "Confirm your SK8 Consign account deletion", fmt.Sprintf(
+		"Hi %s,\n\nWe received a request to permanently delete your account. Enter this code in the app to confirm:\n\n%s\n\n"+
+			"Once confirmed, your profile is anonymised and cannot be recovered. Order records are kept without your personal details for accounting purposes.\n\n"+
+			"This code expires in 1 hour. If you did not request this, change your password immediately.",
+		displayName(&user), token,
+	))
+}
+
+// ConfirmAccountDeletion - langkah kedua: kode dari email harus milik user yang login
+func ConfirmAccountDeletion(actor AuditActor, token string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := consumeUserToken(tx, token, TokenPurposeAccountDeletion)
+		if err != nil {
+			return err
+		}
+
+		if userToken.UserID != actor.UserID {
+			return ErrInvalidUserToken
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", userToken.UserID).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		if err := checkAccountDeletable(tx, &user); err != nil {
+			return err
+		}
+
+		if err := anonymiseAccount(tx, &user); err != nil {
+			return err
+		}
+
+		log.Printf("🗑️  Account deleted and anonymised: %s", user.ID)
+
+		return RecordAudit(tx, actor, AuditUserDelete, "user", user.ID, nil)
+	})
+}
+
+// checkAccountDeletable - akun dengan order / payout yang belum selesai tidak bisa dihapus
+func checkAccountDeletable(tx *gorm.DB, user *models.User) error {
+	var openOrders int64
+	if err := tx.Model(&models.Order{}).
+		Where("user_id = ? AND status IN ?", user.ID, openOrderStatuses).
+		Count(&openOrders).Error; err != nil {
+		return err
+	}
+	if openOrders > 0 {
+		return errors.New("you still have orders in progress, please wait until they are completed")
+	}
+
+	var openSales int64
+	if err := tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("products.user_id = ? AND orders.status IN ?", user.ID, openOrderStatuses).
+		Count(&openSales).Error; err != nil {
+		return err
+	}
+	if openSales > 0 {
+		return errors.New("you still have sales in progress, please fulfil or cancel them first")
+	}
+
+	var pendingPayouts int64
+	if err := tx.Model(&models.ConsignorPayout{}).
+		Where("consignor_id = ? AND status = ?", user.ID, "pending").
+		Count(&pendingPayouts).Error; err != nil {
+		return err
+	}
+	if pendingPayouts > 0 {
+		return errors.New("you still have pending payouts, please wait until they are paid")
+	}
+
+	return nil
+}
+
+// anonymiseAccount - ganti semua PII dengan nilai netral dan hapus data pribadi lain
+func anonymiseAccount(tx *gorm.DB, user *models.User) error {
+	randomPassword, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return err
+	}
+
+	originalUsername := user.Username
+	placeholder := "deleted-" + strings.ReplaceAll(user.ID, "-", "")
+
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"username":              placeholder,
+		"email":                 placeholder + "@deleted.invalid",
+		"full_name":             "",
+		"phone":                 "",
+		"password":              hashedPassword,
+		"is_active":             false,
+		"email_verified_at":     nil,
+		"two_factor_enabled":    false,
+		"two_factor_secret":     "",
+		"two_factor_enabled_at": nil,
+		"locked_until":          nil,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := RevokeUserTokens(tx, user.ID); err != nil {
+		return err
+	}
+
+	// Alamat & catatan di order adalah PII; nominal & item tetap untuk pembukuan
+	if err := tx.Model(&models.Order{}).Unscoped().
+		Where("user_id = ?", user.ID).
+		Updates(map[string]interface{}{"shipping_addr": "", "notes": ""}).Error; err != nil {
+		return err
+	}
+
+	// Listing yang belum terjual (termasuk yang masih di antrean review) ditarik
+	withdrawn := []string{ProductStatusAvailable, ProductStatusPendingReview, ProductStatusChangesRequested}
+	if err := tx.Where("user_id = ? AND status IN ?", user.ID, withdrawn).
+		Delete(&models.Product{}).Error; err != nil {
+		return err
+	}
+
+	cleanups := []interface{}{
+		&models.Cart{},
+		&models.Notification{},
+		&models.UserToken{},
+		&models.RefreshToken{},
+		&models.RecoveryCode{},
+	}
+	for _, model := range cleanups {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("`key` = ?", loginUserKey(originalUsername)).Delete(&models.LoginAttempt{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(user).Error
+}
+
+// ExportUserData - tulis ZIP berisi data pribadi user (JSON per kategori) ke w
+func ExportUserData(actor AuditActor, w io.Writer) error {
+	userID := actor.UserID
+
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	var products []models.Product
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&products).Error; err != nil {
+		return err
+	}
+
+	var orders []models.Order
+	if err := database.DB.Where("user_id = ?", userID).
+		Preload("OrderItems.Product").
+		Preload("StatusHistory").
+		Order("created_at ASC").
+		Find(&orders).Error; err != nil {
+		return err
+	}
+
+	var cartItems []models.Cart
+	if err := database.DB.Where("user_id = ?", userID).Preload("Product").Find(&cartItems).Error; err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&notifications).Error; err != nil {
+		return err
+	}
+
+	productResponses := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		productResponses = append(productResponses, product.ToResponse())
+	}
+
+	orderResponses := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		orderResponses = append(orderResponses, order.ToResponse())
+	}
+
+	notificationResponses := make([]interface{}, 0, len(notifications))
+	for _, notification := range notifications {
+		notificationResponses = append(notificationResponses, map[string]interface{}{
+			"id":         notification.ID,
+			"title":      notification.Title,
+			"message":    notification.Message,
+			"type":       notification.Type,
+			"is_read":    notification.IsRead,
+			"created_at": notification.CreatedAt,
+		})
+	}
+
+	cartResponses := make([]interface{}, 0, len(cartItems))
+	for _, item := range cartItems {
+		cartResponses = append(cartResponses, item.ToResponse())
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user.ToResponse()},
+		{"products.json", productResponses},
+		{"orders.json", orderResponses},
+		{"cart.json", cartResponses},
+		{"notifications.json", notificationResponses},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	if err := RecordAudit(database.DB, actor, AuditUserExport, "user", userID, nil); err != nil {
+		log.Printf("❌ Failed to record data export for %s: %v", userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/mailer"
+	"sk8consign-backend/models"
+	"sk8consign-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tujuan token sekali pakai
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeLoginChallenge    = "login_challenge"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+
+	// Jeda minimum sebelum email yang sama boleh dikirim ulang
+	userTokenResendInterval = time.Minute
+)
+
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+var appMailer mailer.Mailer
+
+// SetMailer - pasang mailer yang dipakai services
+func SetMailer(m mailer.Mailer) {
+	appMailer = m
+}
+
+// GetMailer - mailer aktif (nil jika belum di-setup)
+func GetMailer() mailer.Mailer {
+	return appMailer
+}
+
+// SendVerificationEmail - kirim link verifikasi email ke user
+func SendVerificationEmail(user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return errors.New("email is already verified")
+	}
+
+	if err := checkResendInterval(database.DB, user.ID, TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	token, err := issueUserToken(database.DB, user.ID, TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.AppBaseURL + "/api/auth/verify-email?token=" + url.QueryEscape(token)
+
+	return sendMail(user.Email, "Verify your SK8 Consign email", fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThis link expires in 24 hours. If you did not create an account, you can ignore this email.",
+		displayName(user), link,
+	))
+}
+
+// RequestEmailVerification - kirim ulang email verifikasi untuk user yang login
+func RequestEmailVerification(userID string) error {
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	return SendVerificationEmail(&user)
+}
+
+// ConfirmEmailVerification - tandai email terverifikasi dengan token dari email
+func ConfirmEmailVerification(token string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := consumeUserToken(tx, token, TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userToken.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+}
+
+// RequestPasswordReset - kirim link reset password. Selalu sukses untuk email
+// yang tidak terdaftar supaya endpoint tidak bisa dipakai menebak akun.
+func RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ? AND is_active = ?", email, true).First(&user).Error; err != nil {
+		return nil
+	}
+
+	if err := checkResendInterval(database.DB, user.ID, TokenPurposePasswordReset); err != nil {
+		if errors.Is(err, errUserTokenTooSoon) {
+			return nil
+		}
+		return err
+	}
+
+	return sendPasswordResetEmail(&user,
+		"We received a request to reset your password.",
+		"If you did not request a reset, you can ignore this email.")
+}
+
+// sendPasswordResetEmail - terbitkan token reset dan kirim link-nya; intro & outro menjelaskan alasan email
+func sendPasswordResetEmail(user *models.User, intro, outro string) error {
+	token, err := issueUserToken(database.DB, user.ID, TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.PasswordResetURL + "?token=" + url.QueryEscape(token)
+
+	return sendMail(user.Email, "Reset your SK8 Consign password", fmt.Sprintf(
+		"Hi %s,\n\n%s Open the link below to choose a new password:\n\n%s\n\nOr enter this code in the app: %s\n\nThis link expires in 1 hour. %s",
+		displayName(user), intro, link, token, outro,
+	))
+}
+
+// ResetPassword - ganti password dengan token reset, lalu cabut semua sesi lama
+func ResetPassword(token, newPassword, userAgent, ipAddress string) error {
+	if len(newPassword) < 6 {
+		return errors.New("new password must be at least 6 characters")
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := consumeUserToken(tx, token, TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", userToken.UserID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		if err := RevokeUserTokens(tx, userToken.UserID); err != nil {
+			return err
+		}
+
+		// Pemegang token dianggap sebagai user pemilik akun
+		actor := AuditActor{UserID: userToken.UserID, IPAddress: ipAddress, UserAgent: userAgent}
+		if err := tx.Model(&models.User{}).Where("id = ?", userToken.UserID).Pluck("role", &actor.Role).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, AuditAuthPasswordReset, "user", userToken.UserID, nil)
+	})
+}
+
+var errUserTokenTooSoon = errors.New("please wait a minute before requesting another email")
+
+// checkResendInterval - batasi email token (verifikasi / reset) maksimal satu per interval
+func checkResendInterval(tx *gorm.DB, userID, purpose string) error {
+	var recent int64
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-userTokenResendInterval)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent > 0 {
+		return errUserTokenTooSoon
+	}
+	return nil
+}
+
+// issueUserToken - buat token baru dan batalkan token lama dengan tujuan yang sama
+func issueUserToken(tx *gorm.DB, userID, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", &now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserToken{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+
+	return token, err
+}
+
+// consumeUserToken - tandai token terpakai secara atomik; token hanya bisa dipakai sekali
+func consumeUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidUserToken
+	}
+
+	var userToken models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).First(&userToken).Error; err != nil {
+		return nil, ErrInvalidUserToken
+	}
+
+	now := time.Now()
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", userToken.ID, now).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+
+	return &userToken, nil
+}
+
+// findUserToken - cari token yang masih berlaku tanpa memakainya
+func findUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidUserToken
+	}
+
+	var userToken models.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+		utils.HashToken(token), purpose, time.Now()).First(&userToken).Error; err != nil {
+		return nil, ErrInvalidUserToken
+	}
+
+	return &userToken, nil
+}
+
+func sendMail(to, subject, body string) error {
+	if appMailer == nil {
+		return errors.New("mailer is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := appMailer.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		log.Printf("❌ Failed to send email to %s: %v", to, err)
+		return errors.New("failed to send email")
+	}
+
+	return nil
+}
+
+func displayName(user *models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Username
+}
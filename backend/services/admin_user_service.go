@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"sk8consign-backend/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status user untuk filter admin
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusLocked    = "locked"
+	UserStatusDeleted   = "deleted"
+)
+
+// UserSearchFilter - filter pencarian user di panel admin
+type UserSearchFilter struct {
+	Query  string // cocok sebagian dengan username / email / nama
+	Role   string
+	Status string
+}
+
+// AdminUserDetail - detail user beserta ringkasan aktivitasnya
+type AdminUserDetail struct {
+	User         models.UserResponse `json:"user"`
+	Status       string              `json:"status"`
+	ProductCount int64               `json:"product_count"`
+	OrderCount   int64               `json:"order_count"`
+	DeletedAt    *time.Time          `json:"deleted_at,omitempty"`
+}
+
+// SearchUsers - daftar user dengan pencarian & filter, terbaru lebih dulu
+func SearchUsers(filter UserSearchFilter, limit, offset int) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	query := database.DB.Model(&models.User{})
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR full_name LIKE ?", like, like, like)
+	}
+
+	if filter.Role != "" {
+		if !rbac.IsValidRole(filter.Role) {
+			return nil, 0, fmt.Errorf("invalid role, must be one of: %s", strings.Join(rbac.Roles(), ", "))
+		}
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	now := time.Now()
+	switch filter.Status {
+	case "":
+	case UserStatusActive:
+		query = query.Where("is_active = ? AND (locked_until IS NULL OR locked_until < ?)", true, now)
+	case UserStatusSuspended:
+		query = query.Where("is_active = ?", false)
+	case UserStatusLocked:
+		query = query.Where("locked_until >= ?", now)
+	case UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	default:
+		return nil, 0, errors.New("invalid status, must be one of: active, suspended, locked, deleted")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+// GetAdminUserDetail - detail satu user (termasuk yang sudah dihapus)
+func GetAdminUserDetail(userID string) (*AdminUserDetail, error) {
+	var user models.User
+	if err := database.DB.Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	detail := &AdminUserDetail{
+		User:   user.ToResponse(),
+		Status: userStatus(&user),
+	}
+
+	if user.DeletedAt.Valid {
+		detail.DeletedAt = &user.DeletedAt.Time
+	}
+
+	database.DB.Model(&models.Product{}).Where("user_id = ?", user.ID).Count(&detail.ProductCount)
+	database.DB.Model(&models.Order{}).Where("user_id = ?", user.ID).Count(&detail.OrderCount)
+
+	return detail, nil
+}
+
+// SuspendUser - nonaktifkan akun (bisa diaktifkan lagi) dan cabut semua sesinya
+func SuspendUser(actor AuditActor, userID, reason string) (*models.User, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("suspension reason is required")
+	}
+	if len(reason) > 255 {
+		return nil, errors.New("suspension reason is too long")
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := loadManageableUser(tx, actor, userID, &user); err != nil {
+			return err
+		}
+
+		if !user.IsActive {
+			return errors.New("user is already suspended")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"is_active":         false,
+			"suspended_at":      &now,
+			"suspension_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := RevokeUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditUserSuspend, "user", user.ID, map[string]interface{}{
+			"reason": reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("⛔ User suspended: %s by %s", user.Username, actor.UserID)
+
+	if err := sendMail(user.Email, "Your SK8 Consign account was suspended", fmt.Sprintf(
+		"Hi %s,\n\nYour account has been suspended for the following reason:\n\n%s\n\nIf you believe this is a mistake, please contact support.",
+		displayName(&user), reason,
+	)); err != nil {
+		log.Printf("⚠️  Suspension email for %s not sent: %v", user.Username, err)
+	}
+
+	return &user, nil
+}
+
+// ReactivateUser - aktifkan kembali akun yang ditangguhkan
+func ReactivateUser(actor AuditActor, userID string) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := loadManageableUser(tx, actor, userID, &user); err != nil {
+			return err
+		}
+
+		if user.IsActive {
+			return errors.New("user is not suspended")
+		}
+
+		previousReason := user.SuspensionReason
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"is_active":         true,
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditUserReactivate, "user", user.ID, map[string]interface{}{
+			"previous_reason": previousReason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ User reactivated: %s by %s", user.Username, actor.UserID)
+
+	if _, err := CreateNotification(user.ID, "Account Reactivated",
+		"Your account has been reactivated. Welcome back!", "security"); err != nil {
+		log.Printf("❌ Failed to notify %s about reactivation: %v", user.Username, err)
+	}
+
+	return &user, nil
+}
+
+// ForcePasswordReset - ganti password dengan nilai acak, cabut semua sesi, lalu
+// kirim link reset ke email user. User tidak bisa login sampai memilih password baru.
+func ForcePasswordReset(actor AuditActor, userID string) (*models.User, error) {
+	randomPassword, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+
+	var user models.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := loadManageableUser(tx, actor, userID, &user); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		if err := RevokeUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditUserPasswordReset, "user", user.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔑 Password reset forced for %s by %s", user.Username, actor.UserID)
+
+	if err := sendPasswordResetEmail(&user,
+		"An administrator has reset your password to protect your account, so you need to choose a new one before signing in again.",
+		"If the link expires, request a new one from the \"Forgot password\" screen.",
+	); err != nil {
+		log.Printf("⚠️  Forced reset email for %s not sent: %v", user.Username, err)
+	}
+
+	return &user, nil
+}
+
+// loadManageableUser - ambil user target; admin tidak bisa mengelola dirinya sendiri
+// dan staff tanpa hak role:assign tidak bisa mengelola akun administrator
+func loadManageableUser(tx *gorm.DB, actor AuditActor, userID string, user *models.User) error {
+	if userID == actor.UserID {
+		return errors.New("you cannot perform this action on your own account")
+	}
+
+	if err := tx.Where("id = ?", userID).First(user).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	if rbac.Can(user.Role, rbac.PermRoleAssign) && !rbac.Can(actor.Role, rbac.PermRoleAssign) {
+		return errors.New("you cannot manage administrator accounts")
+	}
+
+	return nil
+}
+
+func userStatus(user *models.User) string {
+	switch {
+	case user.DeletedAt.Valid:
+		return UserStatusDeleted
+	case !user.IsActive:
+		return UserStatusSuspended
+	case user.LockedUntil != nil && user.LockedUntil.After(time.Now()):
+		return UserStatusLocked
+	default:
+		return UserStatusActive
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Aksi audit (resource.aksi)
+const (
+	AuditUserSuspend       = "user.suspend"
+	AuditUserReactivate    = "user.reactivate"
+	AuditUserUnlock        = "user.unlock"
+	AuditUserRoleChange    = "user.role_change"
+	AuditUserPasswordReset = "user.force_password_reset"
+	AuditUserUpdate        = "user.update"
+
+	AuditAuthLogin          = "auth.login"
+	AuditAuthLoginFailed    = "auth.login_failed"
+	AuditAuthPasswordChange = "auth.password_change"
+	AuditAuthPasswordReset  = "auth.password_reset"
+	AuditAuthTwoFactorOn    = "auth.2fa_enable"
+	AuditAuthTwoFactorOff   = "auth.2fa_disable"
+	AuditAuthTwoFactorRule  = "auth.2fa_policy"
+
+	AuditProductCreate = "product.create"
+	AuditProductUpdate = "product.update"
+	AuditProductDelete = "product.delete"
+
+	AuditProductImageAdd    = "product.image_add"
+	AuditProductImageRemove = "product.image_remove"
+
+	AuditProductApprove        = "product.approve"
+	AuditProductReject         = "product.reject"
+	AuditProductRequestChanges = "product.request_changes"
+
+	AuditOrderStatus        = "order.status_change"
+	AuditOrderPaymentStatus = "order.payment_status_change"
+)
+
+// AuditFilter - filter query audit log di panel admin
+type AuditFilter struct {
+	ActorID    string
+	Action     string // exact, atau prefix jika diakhiri "." (mis. "user.")
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditActor - siapa yang melakukan aksi dan dari mana
+type AuditActor struct {
+	UserID    string
+	Role      string
+	IPAddress string
+	UserAgent string
+}
+
+// RecordAudit - tulis satu baris audit log di transaksi caller supaya jejak
+// tersimpan atomik bersama perubahan yang dicatatnya
+func RecordAudit(tx *gorm.DB, actor AuditActor, action, targetType, targetID string, details map[string]interface{}) error {
+	return writeAudit(tx, actor, action, targetType, targetID, nil, nil, details)
+}
+
+// RecordAuditChange - tulis audit log berisi diff before/after. Hanya field yang
+// nilainya berubah yang disimpan; jika tidak ada yang berubah, tidak ada baris ditulis.
+func RecordAuditChange(tx *gorm.DB, actor AuditActor, action, targetType, targetID string, before, after map[string]interface{}) error {
+	changedBefore, changedAfter := auditDiff(before, after)
+	if len(changedBefore) == 0 && len(changedAfter) == 0 {
+		return nil
+	}
+	return writeAudit(tx, actor, action, targetType, targetID, changedBefore, changedAfter, nil)
+}
+
+// recordAuditOrLog - audit di luar transaksi (mis. login); gagal tulis tidak
+// menggagalkan aksi utama, cukup dicatat di log server
+func recordAuditOrLog(actor AuditActor, action, targetType, targetID string, details map[string]interface{}) {
+	if err := RecordAudit(database.DB, actor, action, targetType, targetID, details); err != nil {
+		log.Printf("❌ Failed to record audit %s for %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// SearchAuditLogs - daftar audit log dengan filter, terbaru lebih dulu
+func SearchAuditLogs(filter AuditFilter, limit, offset int) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var total int64
+
+	query := database.DB.Model(&models.AuditLog{})
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			query = query.Where("action LIKE ?", filter.Action+"%")
+		} else {
+			query = query.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, 0, errors.New("from must be before to")
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+
+	return logs, total, err
+}
+
+// auditDiff - ambil hanya key yang nilainya berbeda antara before dan after
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		if ok && auditValue(oldValue) == auditValue(newValue) {
+			continue
+		}
+		changedBefore[key] = oldValue
+		if ok {
+			changedAfter[key] = newValue
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changedAfter[key] = newValue
+		}
+	}
+
+	return changedBefore, changedAfter
+}
+
+// auditValue - bentuk pembanding; pointer nil & nilai kosong dibedakan
+func auditValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case *time.Time:
+		if v == nil {
+			return "<nil>"
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+func writeAudit(tx *gorm.DB, actor AuditActor, action, targetType, targetID string, before, after, details map[string]interface{}) error {
+	entry := models.AuditLog{
+		ID:         uuid.New().String(),
+		ActorRole:  actor.Role,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		Details:    details,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+	}
+
+	if actor.UserID != "" {
+		entry.ActorID = &actor.UserID
+	}
+	if len(entry.UserAgent) > 255 {
+		entry.UserAgent = entry.UserAgent[:255]
+	}
+
+	return tx.Create(&entry).Error
+}
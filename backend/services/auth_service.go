@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
 	"sk8consign-backend/database"
 	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
 	"sk8consign-backend/utils"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -12,35 +16,72 @@ import (
 
 type AuthService struct{}
 
-// Login - authenticate user
-func (s *AuthService) Login(username, password string) (*models.User, string, error) {
+// Login - authenticate user, terbitkan access token + refresh token.
+// Percobaan gagal dicatat per username & IP (back-off eksponensial, lockout).
+// Jika 2FA aktif, yang dikembalikan *TwoFactorRequiredError berisi challenge token.
+func (s *AuthService) Login(username, password, userAgent, ipAddress string) (*models.User, *TokenPair, error) {
+	ctx := context.Background()
+
+	// Tolak jika masih dalam jeda back-off
+	if err := checkLoginThrottle(ctx, username, ipAddress); err != nil {
+		return nil, nil, err
+	}
+
 	var user models.User
 
 	// Cari user by username
 	if err := database.DB.Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", errors.New("username atau password salah")
+			recordLoginFailure(ctx, username, ipAddress, nil)
+			return nil, nil, ErrInvalidCredentials
 		}
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Cek apakah user aktif
-	if !user.IsActive {
-		return nil, "", errors.New("akun tidak aktif")
+	// Cek apakah akun sedang dikunci
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, nil, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil), Locked: true}
 	}
 
 	// Verify password
 	if !utils.CheckPassword(user.Password, password) {
-		return nil, "", errors.New("username atau password salah")
+		recordLoginFailure(ctx, username, ipAddress, &user)
+		recordAuditOrLog(AuditActor{IPAddress: ipAddress, UserAgent: userAgent}, AuditAuthLoginFailed, "user", user.ID,
+			map[string]interface{}{"reason": "invalid_password"})
+		if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+			return nil, nil, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil), Locked: true}
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	// Cek apakah user aktif
+	if !user.IsActive {
+		return nil, nil, ErrAccountInactive
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	// 2FA aktif: login dilanjutkan dengan kode dari authenticator app.
+	// Hitungan gagal baru di-reset setelah langkah kedua berhasil.
+	if user.TwoFactorEnabled {
+		return nil, nil, startTwoFactorChallenge(&user)
+	}
+
+	resetLoginFailures(ctx, username)
+
+	// Generate access token + refresh token
+	tokens, _, err := IssueTokenPair(database.DB, &user, "", userAgent, ipAddress)
 	if err != nil {
-		return nil, "", errors.New("gagal generate token")
+		return nil, nil, errors.New("gagal generate token")
 	}
 
-	return &user, token, nil
+	recordAuditOrLog(loginAuditActor(&user, userAgent, ipAddress), AuditAuthLogin, "user", user.ID,
+		map[string]interface{}{"method": "password"})
+
+	return &user, tokens, nil
+}
+
+// loginAuditActor - actor audit untuk user yang baru berhasil login
+func loginAuditActor(user *models.User, userAgent, ipAddress string) AuditActor {
+	return AuditActor{UserID: user.ID, Role: user.Role, IPAddress: ipAddress, UserAgent: userAgent}
 }
 
 // Register - create new user
@@ -75,7 +116,7 @@ func (s *AuthService) Register(username, email, password, fullName, phone string
 		Password: hashedPassword,
 		FullName: fullName,
 		Phone:    phone,
-		Role:     "user", // default role
+		Role:     rbac.RoleBuyer, // default role
 		IsActive: true,
 	}
 
@@ -84,6 +125,11 @@ func (s *AuthService) Register(username, email, password, fullName, phone string
 		return errors.New("gagal membuat user")
 	}
 
+	// Kirim email verifikasi; gagal kirim tidak menggagalkan register (bisa kirim ulang)
+	if err := SendVerificationEmail(&user); err != nil {
+		log.Printf("⚠️  Verification email for %s not sent: %v", user.Username, err)
+	}
+
 	return nil
 }
 
@@ -135,8 +181,14 @@ func (s *AuthService) ChangePassword(userID, oldPassword, newPassword string) er
 		return errors.New("gagal hash password")
 	}
 
-	// Update password
-	if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
+	// Update password dan cabut semua sesi lama
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return RevokeUserTokens(tx, user.ID)
+	})
+	if err != nil {
 		return errors.New("gagal update password")
 	}
 
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/ledger"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SellerEarnings - ringkasan pendapatan consignor
+type SellerEarnings struct {
+	TotalEarned     float64 `json:"total_earned"`
+	PendingAmount   float64 `json:"pending_amount"`
+	PaidAmount      float64 `json:"paid_amount"`
+	TotalCommission float64 `json:"total_commission"`
+	ItemsSold       int64   `json:"items_sold"`
+}
+
+// CreateConsignmentAgreement - buat perjanjian titip jual baru untuk produk.
+// Perjanjian aktif sebelumnya untuk produk yang sama otomatis di-terminate.
+func CreateConsignmentAgreement(productID, consignorID, commissionType string, commissionRate float64, tiers []models.CommissionTier, floorPrice float64, expiresAt *time.Time) (*models.ConsignmentAgreement, error) {
+	if commissionType == "" {
+		commissionType = "percentage"
+	}
+
+	switch commissionType {
+	case "percentage":
+		if commissionRate < 0 || commissionRate > 100 {
+			return nil, errors.New("commission rate must be between 0 and 100")
+		}
+		tiers = nil
+	case "tiered":
+		if err := validateCommissionTiers(tiers); err != nil {
+			return nil, err
+		}
+		commissionRate = 0
+	default:
+		return nil, errors.New("invalid commission type")
+	}
+
+	if floorPrice < 0 {
+		return nil, errors.New("floor price cannot be negative")
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, errors.New("expiry date must be in the future")
+	}
+
+	var product models.Product
+	if err := database.DB.Where("id = ?", productID).First(&product).Error; err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	if product.Price < floorPrice {
+		return nil, errors.New("product price is below the agreed floor price")
+	}
+
+	var consignor models.User
+	if err := database.DB.Where("id = ? AND is_active = ?", consignorID, true).First(&consignor).Error; err != nil {
+		return nil, errors.New("consignor not found")
+	}
+
+	agreement := &models.ConsignmentAgreement{
+		ID:              uuid.New().String(),
+		ProductID:       productID,
+		ConsignorID:     consignorID,
+		CommissionType:  commissionType,
+		CommissionRate:  commissionRate,
+		CommissionTiers: tiers,
+		FloorPrice:      floorPrice,
+		ExpiresAt:       expiresAt,
+		Status:          "active",
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ConsignmentAgreement{}).
+			Where("product_id = ? AND status = ?", productID, "active").
+			Update("status", "terminated").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(agreement).Error; err != nil {
+			return err
+		}
+
+		// Buyer yang menitipkan barang otomatis menjadi consignor
+		if consignor.Role == rbac.RoleBuyer {
+			return tx.Model(&consignor).Update("role", rbac.RoleConsignor).Error
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Preload("Product").First(agreement, "id = ?", agreement.ID)
+	return agreement, nil
+}
+
+// GetActiveAgreement - ambil perjanjian aktif untuk produk (bisa nil jika tidak ada)
+func GetActiveAgreement(db *gorm.DB, productID string) (*models.ConsignmentAgreement, error) {
+	var agreement models.ConsignmentAgreement
+	err := db.Where("product_id = ? AND status = ?", productID, "active").
+		Order("created_at DESC").
+		First(&agreement).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &agreement, nil
+}
+
+// GetConsignorAgreements - list perjanjian milik consignor
+func GetConsignorAgreements(consignorID, status string) ([]models.ConsignmentAgreement, error) {
+	var agreements []models.ConsignmentAgreement
+
+	query := database.DB.Where("consignor_id = ?", consignorID)
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Preload("Product").
+		Order("created_at DESC").
+		Find(&agreements).Error
+
+	return agreements, err
+}
+
+// CalculateCommission - hitung komisi platform dari harga jual sesuai perjanjian.
+// Tanpa perjanjian dipakai DefaultCommissionRate dari config.
+func CalculateCommission(agreement *models.ConsignmentAgreement, amount float64) float64 {
+	rate := config.AppConfig.DefaultCommissionRate
+
+	if agreement != nil {
+		rate = agreement.CommissionRate
+		if agreement.CommissionType == "tiered" {
+			rate = tierRate(agreement.CommissionTiers, amount)
+		}
+	}
+
+	return roundMoney(amount * rate / 100)
+}
+
+// recordConsignorPayouts - tulis bagian consignor per OrderItem ke payout ledger.
+// Dipanggil di dalam transaksi pembayaran; aman dipanggil ulang karena order_item_id unique.
+func recordConsignorPayouts(tx *gorm.DB, orderID string) ([]models.ConsignorPayout, error) {
+	var orderItems []models.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Preload("Product").Find(&orderItems).Error; err != nil {
+		return nil, err
+	}
+
+	var payouts []models.ConsignorPayout
+	for _, item := range orderItems {
+		var existing int64
+		tx.Model(&models.ConsignorPayout{}).Where("order_item_id = ?", item.ID).Count(&existing)
+		if existing > 0 {
+			continue
+		}
+
+		agreement, err := GetActiveAgreement(tx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		consignorID := item.Product.UserID
+		var agreementID *string
+		if agreement != nil {
+			consignorID = agreement.ConsignorID
+			agreementID = &agreement.ID
+		}
+
+		commission := CalculateCommission(agreement, item.Subtotal)
+
+		payout := models.ConsignorPayout{
+			ID:               uuid.New().String(),
+			ConsignorID:      consignorID,
+			AgreementID:      agreementID,
+			OrderID:          orderID,
+			OrderItemID:      item.ID,
+			ProductID:        item.ProductID,
+			GrossAmount:      item.Subtotal,
+			CommissionAmount: commission,
+			PayoutAmount:     roundMoney(item.Subtotal - commission),
+			Status:           "pending",
+		}
+
+		if err := tx.Create(&payout).Error; err != nil {
+			return nil, err
+		}
+
+		payouts = append(payouts, payout)
+	}
+
+	return payouts, nil
+}
+
+// proceedsFromPayouts - gabungkan payout per consignor untuk jurnal ledger
+func proceedsFromPayouts(payouts []models.ConsignorPayout) []ledger.Proceeds {
+	var proceeds []ledger.Proceeds
+	index := make(map[string]int)
+
+	for _, payout := range payouts {
+		i, ok := index[payout.ConsignorID]
+		if !ok {
+			i = len(proceeds)
+			index[payout.ConsignorID] = i
+			proceeds = append(proceeds, ledger.Proceeds{ConsignorID: payout.ConsignorID})
+		}
+		proceeds[i].Gross += payout.GrossAmount
+		proceeds[i].Commission += payout.CommissionAmount
+	}
+
+	return proceeds
+}
+
+// GetSellerEarnings - ringkasan earned vs pending untuk consignor
+func GetSellerEarnings(consignorID string) (*SellerEarnings, error) {
+	var rows []struct {
+		Status     string
+		Payout     float64
+		Commission float64
+		Items      int64
+	}
+
+	err := database.DB.Model(&models.ConsignorPayout{}).
+		Select("status, COALESCE(SUM(payout_amount - refunded_amount), 0) AS payout, COALESCE(SUM(commission_amount), 0) AS commission, COUNT(*) AS items").
+		Where("consignor_id = ?", consignorID).
+		Group("status").
+		Scan(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	earnings := &SellerEarnings{}
+	for _, row := range rows {
+		// Payout "reversed" sudah nol setelah dikurangi refund
+		switch row.Status {
+		case "pending":
+			earnings.PendingAmount += row.Payout
+		case "paid":
+			earnings.PaidAmount += row.Payout
+		}
+		earnings.TotalCommission += row.Commission
+		earnings.ItemsSold += row.Items
+	}
+	earnings.TotalEarned = roundMoney(earnings.PendingAmount + earnings.PaidAmount)
+
+	return earnings, nil
+}
+
+// GetSellerPayouts - list baris payout ledger milik consignor
+func GetSellerPayouts(consignorID, status string, limit, offset int) ([]models.ConsignorPayout, int64, error) {
+	var payouts []models.ConsignorPayout
+	var total int64
+
+	query := database.DB.Model(&models.ConsignorPayout{}).Where("consignor_id = ?", consignorID)
+
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+
+	err := query.Preload("Product").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&payouts).Error
+
+	return payouts, total, err
+}
+
+// MarkPayoutPaid - tandai payout sudah ditransfer ke consignor
+func MarkPayoutPaid(payoutID string) (*models.ConsignorPayout, error) {
+	var payout models.ConsignorPayout
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris payout supaya dua request bersamaan tidak memposting jurnal dua kali
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", payoutID).First(&payout).Error; err != nil {
+			return errors.New("payout not found")
+		}
+
+		if payout.Status != "pending" {
+			return errors.New("payout is not pending")
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.ConsignorPayout{}).
+			Where("id = ? AND status = ?", payout.ID, "pending").
+			Updates(map[string]interface{}{
+				"status":  "paid",
+				"paid_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("payout is not pending")
+		}
+
+		// Bagian yang sudah dibalik karena refund tidak ikut ditransfer. Payout nol
+		// (komisi 100% atau refund penuh) tidak punya uang yang berpindah, jadi tanpa jurnal.
+		amount := roundMoney(payout.PayoutAmount - payout.RefundedAmount)
+		if amount <= 0 {
+			return nil
+		}
+
+		_, err := ledger.PostPayout(tx, payout.ID, payout.ConsignorID, amount)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Preload("Product").First(&payout, "id = ?", payout.ID)
+	return &payout, nil
+}
+
+// checkConsignmentTerms - validasi produk masih dalam masa perjanjian saat checkout
+func checkConsignmentTerms(db *gorm.DB, product models.Product) error {
+	agreement, err := GetActiveAgreement(db, product.ID)
+	if err != nil {
+		return err
+	}
+
+	if agreement == nil {
+		return nil
+	}
+
+	if agreement.IsExpired(time.Now()) {
+		return errors.New("consignment agreement for " + product.Name + " has expired")
+	}
+
+	if product.Price < agreement.FloorPrice {
+		return errors.New("price of " + product.Name + " is below the agreed floor price")
+	}
+
+	return nil
+}
+
+func validateCommissionTiers(tiers []models.CommissionTier) error {
+	if len(tiers) == 0 {
+		return errors.New("commission tiers are required for tiered commission")
+	}
+
+	var lastUpTo float64
+	for i, tier := range tiers {
+		if tier.Rate < 0 || tier.Rate > 100 {
+			return errors.New("commission rate must be between 0 and 100")
+		}
+
+		isLast := i == len(tiers)-1
+		if tier.UpTo == 0 && !isLast {
+			return errors.New("only the last commission tier may be unbounded")
+		}
+		if tier.UpTo != 0 && tier.UpTo <= lastUpTo {
+			return errors.New("commission tiers must be in ascending order")
+		}
+		lastUpTo = tier.UpTo
+	}
+
+	return nil
+}
+
+// tierRate - rate dari tier pertama yang batasnya mencakup amount
+func tierRate(tiers []models.CommissionTier, amount float64) float64 {
+	for _, tier := range tiers {
+		if tier.UpTo == 0 || amount <= tier.UpTo {
+			return tier.Rate
+		}
+	}
+
+	if len(tiers) > 0 {
+		return tiers[len(tiers)-1].Rate
+	}
+
+	return config.AppConfig.DefaultCommissionRate
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sync"
+	"time"
+)
+
+// Tipe domain event
+const (
+	EventOrderCreated     = "order.created"
+	EventPaymentConfirmed = "payment.confirmed"
+	EventOrderShipped     = "order.shipped"
+	EventOrderDelivered   = "order.delivered"
+	EventOrderCompleted   = "order.completed"
+	EventOrderCancelled   = "order.cancelled"
+	EventOrderExpired     = "order.expired"
+	EventOrderRefunded    = "order.refunded"
+	EventProductSold      = "product.sold"
+	EventReturnRequested  = "return.requested"
+	EventReturnApproved   = "return.approved"
+	EventReturnRejected   = "return.rejected"
+	EventRefundIssued     = "refund.issued"
+	EventLatePayment      = "payment.late_refunded"
+
+	// Review listing consignor
+	EventProductSubmitted        = "product.submitted"
+	EventProductApproved         = "product.approved"
+	EventProductRejected         = "product.rejected"
+	EventProductChangesRequested = "product.changes_requested"
+)
+
+// Event - domain event yang dipublish setelah transaksi commit
+type Event struct {
+	Type       string
+	Order      *models.Order
+	Product    *models.Product
+	Note       string
+	Amount     float64 // nominal refund untuk event refund / retur
+	OccurredAt time.Time
+}
+
+// EventHandler - subscriber untuk satu tipe event
+type EventHandler func(event Event) error
+
+// EventBus - pub/sub in-process sederhana. Handler dijalankan berurutan di
+// goroutine publisher; error dan panic dari handler hanya di-log.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+// NewEventBus - buat event bus kosong
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]EventHandler)}
+}
+
+// Events - event bus default untuk package services
+var Events = NewEventBus()
+
+// Subscribe - daftarkan handler untuk satu atau beberapa tipe event
+func (b *EventBus) Subscribe(handler EventHandler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, eventType := range eventTypes {
+		b.handlers[eventType] = append(b.handlers[eventType], handler)
+	}
+}
+
+// Publish - kirim event ke semua subscriber
+func (b *EventBus) Publish(events ...Event) {
+	for _, event := range events {
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+
+		b.mu.RLock()
+		handlers := append([]EventHandler(nil), b.handlers[event.Type]...)
+		b.mu.RUnlock()
+
+		for _, handler := range handlers {
+			b.dispatch(handler, event)
+		}
+	}
+}
+
+func (b *EventBus) dispatch(handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Event handler panic (%s): %v", event.Type, r)
+		}
+	}()
+
+	if err := handler(event); err != nil {
+		log.Printf("❌ Event handler failed (%s): %v", event.Type, err)
+	}
+}
+
+// orderStatusEvents - event yang muncul ketika order masuk ke status tertentu
+var orderStatusEvents = map[string]string{
+	OrderStatusPending:   EventOrderCreated,
+	OrderStatusConfirmed: EventPaymentConfirmed,
+	OrderStatusShipped:   EventOrderShipped,
+	OrderStatusDelivered: EventOrderDelivered,
+	OrderStatusCompleted: EventOrderCompleted,
+	OrderStatusCancelled: EventOrderCancelled,
+	OrderStatusRefunded:  EventOrderRefunded,
+}
+
+// publishOrderStatusEvent - publish event untuk status order terbaru.
+// Dipanggil setelah transaksi commit supaya subscriber melihat data final.
+func publishOrderStatusEvent(orderID, status, note string) {
+	eventType, ok := orderStatusEvents[status]
+	if !ok {
+		return
+	}
+
+	publishOrderEvent(orderID, eventType, note)
+}
+
+// publishOrderEvent - load order lalu publish event beserta event turunannya
+func publishOrderEvent(orderID, eventType, note string) {
+	order, ok := loadEventOrder(orderID, eventType)
+	if !ok {
+		return
+	}
+
+	events := []Event{{Type: eventType, Order: order, Note: note}}
+
+	if eventType == EventPaymentConfirmed {
+		for i := range order.OrderItems {
+			events = append(events, Event{
+				Type:    EventProductSold,
+				Order:   order,
+				Product: &order.OrderItems[i].Product,
+			})
+		}
+	}
+
+	Events.Publish(events...)
+}
+
+// publishRefundEvent - publish event refund / retur beserta nominalnya
+func publishRefundEvent(orderID, eventType string, amount float64, note string) {
+	order, ok := loadEventOrder(orderID, eventType)
+	if !ok {
+		return
+	}
+
+	Events.Publish(Event{Type: eventType, Order: order, Note: note, Amount: amount})
+}
+
+// publishProductEvent - publish event listing (tanpa order), mis. hasil review
+func publishProductEvent(product *models.Product, eventType, note string) {
+	Events.Publish(Event{Type: eventType, Product: product, Note: note})
+}
+
+func loadEventOrder(orderID, eventType string) (*models.Order, bool) {
+	var order models.Order
+	if err := database.DB.Preload("OrderItems.Product").First(&order, "id = ?", orderID).Error; err != nil {
+		log.Printf("❌ Failed to load order %s for event %s: %v", orderID, eventType, err)
+		return nil, false
+	}
+
+	return &order, true
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"sk8consign-backend/database"
+	"sk8consign-backend/ledger"
+	"time"
+)
+
+// GetTrialBalance - trial balance semua akun ledger sampai waktu asOf
+func GetTrialBalance(asOf time.Time) (*ledger.TrialBalanceReport, error) {
+	return ledger.TrialBalance(database.DB, asOf)
+}
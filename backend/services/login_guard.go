@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptStore - penyimpanan hitungan login gagal. Implementasi DB dipakai
+// sekarang; interface ini supaya nanti bisa dipindah ke Redis (INCR + EXPIRE).
+type LoginAttemptStore interface {
+	// Get - jumlah gagal berturut-turut dan waktu gagal terakhir (0 jika tidak ada / sudah kedaluwarsa)
+	Get(ctx context.Context, key string) (failures int, lastFailure time.Time, err error)
+
+	// RecordFailure - tambah satu kegagalan, mengembalikan jumlah terbaru
+	RecordFailure(ctx context.Context, key string, now time.Time) (int, error)
+
+	// Reset - hapus hitungan untuk key
+	Reset(ctx context.Context, key string) error
+}
+
+// loginAttemptWindow - hitungan gagal kedaluwarsa jika tidak ada kegagalan baru selama ini
+const loginAttemptWindow = 24 * time.Hour
+
+// Back-off: setelah beberapa kegagalan gratis, jeda antar percobaan berlipat dua
+const (
+	loginFreeFailuresPerUser = 3
+	loginFreeFailuresPerIP   = 10
+	loginBaseDelay           = time.Second
+	loginMaxDelay            = 15 * time.Minute
+)
+
+var (
+	ErrInvalidCredentials = errors.New("username atau password salah")
+	ErrAccountInactive    = errors.New("akun tidak aktif")
+)
+
+// LoginThrottledError - login ditolak sementara; RetryAfter untuk header Retry-After
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	wait := e.RetryAfter.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+
+	if e.Locked {
+		return fmt.Sprintf("akun terkunci sementara, coba lagi dalam %s", wait)
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login, coba lagi dalam %s", wait)
+}
+
+// DBLoginAttemptStore - LoginAttemptStore di tabel login_attempts
+type DBLoginAttemptStore struct {
+	DB *gorm.DB
+}
+
+func (s *DBLoginAttemptStore) Get(ctx context.Context, key string) (int, time.Time, error) {
+	var attempt models.LoginAttempt
+	err := s.DB.WithContext(ctx).Where("`key` = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if time.Since(attempt.LastFailureAt) > loginAttemptWindow {
+		return 0, time.Time{}, nil
+	}
+
+	return attempt.Failures, attempt.LastFailureAt, nil
+}
+
+func (s *DBLoginAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time) (int, error) {
+	db := s.DB.WithContext(ctx)
+
+	// Upsert atomik; hitungan yang sudah kedaluwarsa mulai lagi dari 1
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-loginAttemptWindow)),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}).Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var attempt models.LoginAttempt
+	if err := db.Where("`key` = ?", key).First(&attempt).Error; err != nil {
+		return 0, err
+	}
+
+	return attempt.Failures, nil
+}
+
+func (s *DBLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.DB.WithContext(ctx).Where("`key` = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+var loginAttempts LoginAttemptStore
+
+// SetLoginAttemptStore - ganti penyimpanan hitungan login gagal
+func SetLoginAttemptStore(store LoginAttemptStore) {
+	loginAttempts = store
+}
+
+func getLoginAttemptStore() LoginAttemptStore {
+	if loginAttempts == nil {
+		loginAttempts = &DBLoginAttemptStore{DB: database.DB}
+	}
+	return loginAttempts
+}
+
+func loginUserKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginBackoff - jeda wajib setelah failures kegagalan
+func loginBackoff(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+
+	delay := float64(loginBaseDelay) * math.Pow(2, float64(failures-free-1))
+	if delay > float64(loginMaxDelay) {
+		return loginMaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// checkLoginThrottle - tolak percobaan yang datang sebelum jeda back-off habis
+func checkLoginThrottle(ctx context.Context, username, ip string) error {
+	store := getLoginAttemptStore()
+	now := time.Now()
+
+	checks := []struct {
+		key  string
+		free int
+	}{
+		{loginUserKey(username), loginFreeFailuresPerUser},
+		{loginIPKey(ip), loginFreeFailuresPerIP},
+	}
+
+	var wait time.Duration
+	for _, check := range checks {
+		failures, lastFailure, err := store.Get(ctx, check.key)
+		if err != nil {
+			return err
+		}
+
+		if remaining := lastFailure.Add(loginBackoff(failures, check.free)).Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure - catat kegagalan per username & IP, kunci akun jika melewati batas
+func recordLoginFailure(ctx context.Context, username, ip string, user *models.User) {
+	store := getLoginAttemptStore()
+	now := time.Now()
+
+	if _, err := store.RecordFailure(ctx, loginIPKey(ip), now); err != nil {
+		log.Printf("❌ Failed to record login failure for %s: %v", ip, err)
+	}
+
+	failures, err := store.RecordFailure(ctx, loginUserKey(username), now)
+	if err != nil {
+		log.Printf("❌ Failed to record login failure for %s: %v", username, err)
+		return
+	}
+
+	if user == nil || failures < config.AppConfig.LoginMaxFailures {
+		return
+	}
+
+	if err := lockAccount(ctx, user, now.Add(config.AppConfig.LoginLockoutPeriod)); err != nil {
+		log.Printf("❌ Failed to lock account %s: %v", user.Username, err)
+	}
+}
+
+// lockAccount - kunci akun sampai waktu tertentu, reset hitungan gagal per user,
+// lalu beri tahu pemiliknya
+func lockAccount(ctx context.Context, user *models.User, until time.Time) error {
+	result := database.DB.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (locked_until IS NULL OR locked_until < ?)", user.ID, time.Now()).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	user.LockedUntil = &until
+	log.Printf("🔒 Account locked: %s until %s", user.Username, until.Format(time.RFC3339))
+
+	// Kunci menggantikan hitungan gagal; selama terkunci login ditolak sebelum password
+	// dicek, jadi setelah kunci habis user mulai lagi dengan jatah percobaan penuh
+	// (bukan langsung terkunci lagi karena satu salah ketik)
+	if err := getLoginAttemptStore().Reset(ctx, loginUserKey(user.Username)); err != nil {
+		log.Printf("❌ Failed to reset login failures for %s: %v", user.Username, err)
+	}
+
+	_, err := CreateNotification(user.ID, "Account Locked",
+		fmt.Sprintf("Your account was locked until %s after too many failed login attempts. If this wasn't you, reset your password.", until.Format("02 Jan 2006 15:04")),
+		"security")
+	if err != nil {
+		return err
+	}
+
+	if err := sendMail(user.Email, "Your SK8 Consign account was locked", fmt.Sprintf(
+		"Hi %s,\n\nWe locked your account until %s because of too many failed login attempts.\n\nIf this wasn't you, we recommend resetting your password once the lock expires.",
+		displayName(user), until.Format("02 Jan 2006 15:04 MST"),
+	)); err != nil {
+		log.Printf("⚠️  Lockout email for %s not sent: %v", user.Username, err)
+	}
+
+	return nil
+}
+
+// resetLoginFailures - login sukses menghapus hitungan username (hitungan IP tetap)
+func resetLoginFailures(ctx context.Context, username string) {
+	if err := getLoginAttemptStore().Reset(ctx, loginUserKey(username)); err != nil {
+		log.Printf("❌ Failed to reset login failures for %s: %v", username, err)
+	}
+}
+
+// UnlockAccount - admin membuka kunci akun dan menghapus hitungan login gagal
+func UnlockAccount(ctx context.Context, actor AuditActor, userID string) (*models.User, error) {
+	var user models.User
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		lockedUntil := user.LockedUntil
+		if err := tx.Model(&user).Update("locked_until", nil).Error; err != nil {
+			return err
+		}
+		user.LockedUntil = nil
+
+		return RecordAudit(tx, actor, AuditUserUnlock, "user", user.ID, map[string]interface{}{
+			"locked_until": lockedUntil,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := getLoginAttemptStore().Reset(ctx, loginUserKey(user.Username)); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/payment"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBOnce sync.Once
+
+// setupTestDB - koneksi ke MySQL uji dari TEST_DATABASE_DSN (mis.
+// root:@tcp(localhost:3306)/sk8consign_test?parseTime=True&loc=Local).
+// Test yang butuh database di-skip jika variabel tidak diisi.
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set, skipping database test")
+	}
+
+	testDBOnce.Do(func() {
+		config.AppConfig = &config.Config{
+			Env:                   "test",
+			ReservationTTL:        30 * time.Minute,
+			DefaultCommissionRate: 20,
+		}
+
+		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			t.Fatalf("connect test database: %v", err)
+		}
+		database.DB = db
+		database.AutoMigrate()
+	})
+
+	if database.DB == nil {
+		t.Fatal("test database is not available")
+	}
+}
+
+// createTestUser - user baru dengan role tertentu; dihapus setelah test selesai
+func createTestUser(t *testing.T, role string) *models.User {
+	t.Helper()
+
+	id := uuid.New().String()
+	user := &models.User{
+		ID:       id,
+		Username: "test_" + id[:8],
+		Email:    "test_" + id[:8] + "@sk8consign.test",
+		Password: "x",
+		FullName: "Test " + role,
+		Role:     role,
+		IsActive: true,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatalf("create test user: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.DB.Unscoped().Where("user_id = ?", id).Delete(&models.Cart{})
+		database.DB.Unscoped().Delete(&models.User{}, "id = ?", id)
+	})
+	return user
+}
+
+// createTestProduct - produk available milik seller; order yang memakainya ikut dihapus
+func createTestProduct(t *testing.T, seller *models.User, price float64) *models.Product {
+	t.Helper()
+
+	product := &models.Product{
+		ID:        uuid.New().String(),
+		UserID:    seller.ID,
+		Name:      "Test Deck",
+		Price:     price,
+		Category:  "accessories",
+		Condition: "new",
+		Status:    ProductStatusAvailable,
+		IsActive:  true,
+		Version:   1,
+	}
+	if err := database.DB.Create(product).Error; err != nil {
+		t.Fatalf("create test product: %v", err)
+	}
+
+	t.Cleanup(func() {
+		orderIDs := database.DB.Model(&models.OrderItem{}).Select("order_id").Where("product_id = ?", product.ID)
+		database.DB.Where("order_id IN (?)", orderIDs).Delete(&models.OrderStatusHistory{})
+		database.DB.Where("product_id = ?", product.ID).Delete(&models.Reservation{})
+		database.DB.Unscoped().Where("id IN (?)", orderIDs).Delete(&models.Order{})
+		database.DB.Where("product_id = ?", product.ID).Delete(&models.OrderItem{})
+		database.DB.Unscoped().Delete(&models.Product{}, "id = ?", product.ID)
+	})
+	return product
+}
+
+// useFakePaymentProvider - pasang fake provider selama test berjalan
+func useFakePaymentProvider(t *testing.T) *payment.FakeProvider {
+	t.Helper()
+
+	previous := paymentProvider
+	fake := payment.NewFakeProvider("test-webhook-secret", "")
+	SetPaymentProvider(fake)
+	t.Cleanup(func() { SetPaymentProvider(previous) })
+
+	return fake
+}
+
+// createTestOrder - checkout satu produk oleh buyer; order masih pending
+func createTestOrder(t *testing.T, buyer *models.User, product *models.Product) *models.Order {
+	t.Helper()
+
+	cart := models.Cart{ID: uuid.New().String(), UserID: buyer.ID, ProductID: product.ID, Quantity: 1}
+	if err := database.DB.Create(&cart).Error; err != nil {
+		t.Fatalf("create cart: %v", err)
+	}
+
+	order, err := CreateOrder(buyer.ID, "bank_transfer", "Jl. Test 1", "")
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.DB.Where("order_id = ?", order.ID).Delete(&models.Refund{})
+		database.DB.Where("order_id = ?", order.ID).Delete(&models.ConsignorPayout{})
+		database.DB.Unscoped().Where("order_id = ?", order.ID).Delete(&models.PaymentIntent{})
+	})
+	return order
+}
+
+// payTestOrder - buat payment intent lalu kirim webhook "paid" dari fake provider
+func payTestOrder(t *testing.T, fake *payment.FakeProvider, order *models.Order) *models.PaymentIntent {
+	t.Helper()
+
+	intent, err := CreatePaymentIntent(context.Background(), order.ID, order.UserID, "")
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	if err := deliverPaymentWebhook(fake, intent, payment.StatusPaid); err != nil {
+		t.Fatalf("HandlePaymentWebhook: %v", err)
+	}
+	return intent
+}
+
+func deliverPaymentWebhook(fake *payment.FakeProvider, intent *models.PaymentIntent, status string) error {
+	body, header, err := fake.SignedWebhook(payment.WebhookEvent{
+		Reference: intent.Reference,
+		OrderID:   intent.OrderID,
+		Status:    status,
+		Amount:    intent.Amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	return HandlePaymentWebhook(context.Background(), header, body)
+}
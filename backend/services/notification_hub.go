@@ -0,0 +1,85 @@
+package services
+
+import (
+	"sync"
+)
+
+// Tipe event stream notifikasi
+const (
+	StreamEventNotification = "notification"
+	StreamEventUnreadCount  = "unread_count"
+	StreamEventSessionEnded = "session_ended" // token kedaluwarsa / dicabut; stream ditutup
+)
+
+// NotificationStreamEvent - satu pesan yang dikirim ke client stream
+type NotificationStreamEvent struct {
+	ID   string // ID notifikasi, kosong untuk unread_count
+	Type string
+	Data interface{}
+}
+
+// NotificationHub - fan-out in-process event notifikasi per user ID.
+// Subscriber yang lambat tidak memblokir publisher: event di-drop dan client
+// bisa menyusul lewat replay Last-Event-ID saat reconnect.
+type NotificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan NotificationStreamEvent]struct{}
+}
+
+// NewNotificationHub - buat hub kosong
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{
+		subscribers: make(map[string]map[chan NotificationStreamEvent]struct{}),
+	}
+}
+
+// NotificationStream - hub default yang dipakai CreateNotification dan stream handler
+var NotificationStream = NewNotificationHub()
+
+// Subscribe - daftarkan koneksi baru untuk user; panggil fungsi yang dikembalikan untuk berhenti
+func (h *NotificationHub) Subscribe(userID string) (<-chan NotificationStreamEvent, func()) {
+	ch := make(chan NotificationStreamEvent, 32)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan NotificationStreamEvent]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish - kirim event ke semua koneksi milik user
+func (h *NotificationHub) Publish(userID string, event NotificationStreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscriberCount - jumlah koneksi aktif milik user
+func (h *NotificationHub) SubscriberCount(userID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers[userID])
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// CreateNotification - simpan notifikasi lalu langsung push ke stream SSE. Panggil
+// setelah transaksi commit supaya client tidak menerima notifikasi untuk perubahan
+// yang di-rollback.
 func CreateNotification(userID, title, message, notifType string) (*models.Notification, error) {
 	notification := &models.Notification{
 		ID:      uuid.New().String(),
@@ -22,6 +25,13 @@ func CreateNotification(userID, title, message, notifType string) (*models.Notif
 		return nil, err
 	}
 
+	NotificationStream.Publish(userID, NotificationStreamEvent{
+		ID:   notification.ID,
+		Type: StreamEventNotification,
+		Data: notification.ToResponse(),
+	})
+	publishUnreadCount(userID)
+
 	return notification, nil
 }
 
@@ -53,13 +63,21 @@ func MarkAsRead(notificationID, userID string) error {
 		return errors.New("notification not found")
 	}
 
+	publishUnreadCount(userID)
 	return nil
 }
 
 func MarkAllAsRead(userID string) error {
-	return database.DB.Model(&models.Notification{}).
+	err := database.DB.Model(&models.Notification{}).
 		Where("user_id = ? AND is_read = ?", userID, false).
 		Update("is_read", true).Error
+
+	if err != nil {
+		return err
+	}
+
+	publishUnreadCount(userID)
+	return nil
 }
 
 func GetUnreadCount(userID string) (int64, error) {
@@ -71,5 +89,36 @@ func GetUnreadCount(userID string) (int64, error) {
 	return count, err
 }
 
+// GetNotificationsSince - notifikasi yang dibuat setelah lastEventID, untuk replay stream
+func GetNotificationsSince(userID, lastEventID string, limit int) ([]models.Notification, error) {
+	var last models.Notification
+	if err := database.DB.Where("id = ? AND user_id = ?", lastEventID, userID).First(&last).Error; err != nil {
+		return nil, errors.New("notification not found")
+	}
+
+	var notifications []models.Notification
+	err := database.DB.Where("user_id = ?", userID).
+		Where("created_at > ? OR (created_at = ? AND id > ?)", last.CreatedAt, last.CreatedAt, last.ID).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&notifications).Error
+
+	return notifications, err
+}
+
+// publishUnreadCount - push unread count terbaru ke stream user
+func publishUnreadCount(userID string) {
+	if NotificationStream.SubscriberCount(userID) == 0 {
+		return
+	}
 
+	count, err := GetUnreadCount(userID)
+	if err != nil {
+		return
+	}
 
+	NotificationStream.Publish(userID, NotificationStreamEvent{
+		Type: StreamEventUnreadCount,
+		Data: map[string]interface{}{"unread_count": count},
+	})
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// notificationTemplate - template notifikasi untuk satu penerima event
+type notificationTemplate struct {
+	notifType string
+	title     string
+	message   *template.Template
+}
+
+// eventNotificationTemplates - template untuk buyer dan seller per tipe event
+var eventNotificationTemplates = map[string]struct {
+	buyer  *notificationTemplate
+	seller *notificationTemplate
+}{
+	EventOrderCreated: {
+		buyer:  newNotificationTemplate("order", "Order Placed", "Your order #{{.OrderRef}} for {{.Amount}} has been placed. Please complete the payment."),
+		seller: newNotificationTemplate("order", "New Order", "{{.ProductNames}} from your listings was ordered in #{{.OrderRef}} and is awaiting payment."),
+	},
+	EventPaymentConfirmed: {
+		buyer: newNotificationTemplate("payment", "Payment Confirmed", "We received your payment of {{.Amount}} for order #{{.OrderRef}}. The seller will ship it soon."),
+	},
+	EventProductSold: {
+		seller: newNotificationTemplate("product", "Item Sold", "{{.ProductName}} has been sold for {{.ProductPrice}} in order #{{.OrderRef}}. Please prepare it for shipping."),
+	},
+	EventOrderShipped: {
+		buyer: newNotificationTemplate("order", "Order Shipped", "Your order #{{.OrderRef}} is on its way.{{if .Note}} {{.Note}}{{end}}"),
+	},
+	EventOrderDelivered: {
+		buyer:  newNotificationTemplate("order", "Order Delivered", "Order #{{.OrderRef}} has been delivered. Please confirm once you have checked the item."),
+		seller: newNotificationTemplate("order", "Order Delivered", "Order #{{.OrderRef}} containing {{.ProductNames}} has been delivered to the buyer."),
+	},
+	EventOrderCompleted: {
+		seller: newNotificationTemplate("order", "Order Completed", "Order #{{.OrderRef}} is completed. Your earnings for {{.ProductNames}} are ready for payout."),
+	},
+	EventOrderCancelled: {
+		buyer:  newNotificationTemplate("order", "Order Cancelled", "Your order #{{.OrderRef}} has been cancelled.{{if .Note}} Reason: {{.Note}}{{end}}"),
+		seller: newNotificationTemplate("order", "Order Cancelled", "Order #{{.OrderRef}} for {{.ProductNames}} has been cancelled. The item is available again."),
+	},
+	EventOrderExpired: {
+		buyer:  newNotificationTemplate("order", "Order Expired", "Order #{{.OrderRef}} was cancelled because payment was not received in time. The items have been released."),
+		seller: newNotificationTemplate("order", "Order Expired", "Order #{{.OrderRef}} was not paid in time. {{.ProductNames}} is available again."),
+	},
+	EventOrderRefunded: {
+		buyer:  newNotificationTemplate("payment", "Order Refunded", "Order #{{.OrderRef}} has been refunded{{if .RefundAmount}} ({{.RefundAmount}}){{end}}."),
+		seller: newNotificationTemplate("order", "Order Refunded", "Order #{{.OrderRef}} for {{.ProductNames}} has been refunded to the buyer. Your earnings have been adjusted."),
+	},
+	EventRefundIssued: {
+		buyer:  newNotificationTemplate("payment", "Refund Issued", "A refund of {{.RefundAmount}} for order #{{.OrderRef}} has been issued.{{if .Note}} {{.Note}}{{end}}"),
+		seller: newNotificationTemplate("order", "Refund Issued", "A partial refund was issued for order #{{.OrderRef}}. Your earnings for {{.ProductNames}} have been adjusted."),
+	},
+	EventLatePayment: {
+		buyer: newNotificationTemplate("payment", "Payment Refunded", "We received {{.RefundAmount}} for order #{{.OrderRef}} after it was no longer awaiting payment. The payment is being refunded to you."),
+	},
+	EventReturnRequested: {
+		seller: newNotificationTemplate("order", "Return Requested", "The buyer requested a return for order #{{.OrderRef}}. Reason: {{.Note}}"),
+	},
+	EventReturnApproved: {
+		buyer:  newNotificationTemplate("payment", "Return Approved", "Your return for order #{{.OrderRef}} was approved. A refund of {{.RefundAmount}} is on its way."),
+		seller: newNotificationTemplate("order", "Return Approved", "The return for order #{{.OrderRef}} was approved. Returned items are listed again and your earnings have been adjusted."),
+	},
+	EventReturnRejected: {
+		buyer: newNotificationTemplate("order", "Return Rejected", "Your return for order #{{.OrderRef}} was rejected. Reason: {{.Note}}"),
+	},
+	EventProductSubmitted: {
+		seller: newNotificationTemplate("product", "Listing Submitted", "{{.ProductName}} was submitted and is waiting for review. We'll let you know once it has been checked."),
+	},
+	EventProductApproved: {
+		seller: newNotificationTemplate("product", "Listing Approved", "{{.ProductName}} was approved and is now live for {{.ProductPrice}}."),
+	},
+	EventProductRejected: {
+		seller: newNotificationTemplate("product", "Listing Rejected", "{{.ProductName}} was not approved. Reason: {{.Note}}"),
+	},
+	EventProductChangesRequested: {
+		seller: newNotificationTemplate("product", "Changes Requested", "{{.ProductName}} needs changes before it can go live: {{.Note}} Edit the listing to resubmit it."),
+	},
+}
+
+func newNotificationTemplate(notifType, title, message string) *notificationTemplate {
+	return &notificationTemplate{
+		notifType: notifType,
+		title:     title,
+		message:   template.Must(template.New(title).Parse(message)),
+	}
+}
+
+// RegisterNotificationSubscriber - ubah domain event menjadi notifikasi buyer & seller
+func RegisterNotificationSubscriber(bus *EventBus) {
+	eventTypes := make([]string, 0, len(eventNotificationTemplates))
+	for eventType := range eventNotificationTemplates {
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	bus.Subscribe(notifyFromEvent, eventTypes...)
+}
+
+func notifyFromEvent(event Event) error {
+	templates, ok := eventNotificationTemplates[event.Type]
+	if !ok || (event.Order == nil && event.Product == nil) {
+		return nil
+	}
+
+	if templates.buyer != nil && event.Order != nil {
+		if err := sendTemplatedNotification(event.Order.UserID, templates.buyer, notificationData(event, "")); err != nil {
+			return err
+		}
+	}
+
+	if templates.seller != nil {
+		for _, sellerID := range eventSellerIDs(event) {
+			if err := sendTemplatedNotification(sellerID, templates.seller, notificationData(event, sellerID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func sendTemplatedNotification(userID string, tmpl *notificationTemplate, data map[string]string) error {
+	var message bytes.Buffer
+	if err := tmpl.message.Execute(&message, data); err != nil {
+		return err
+	}
+
+	_, err := CreateNotification(userID, tmpl.title, message.String(), tmpl.notifType)
+	return err
+}
+
+// eventSellerIDs - seller yang terlibat: pemilik produk event, atau semua pemilik item order
+func eventSellerIDs(event Event) []string {
+	if event.Product != nil {
+		return []string{event.Product.UserID}
+	}
+
+	var sellerIDs []string
+	for _, item := range event.Order.OrderItems {
+		if !containsString(sellerIDs, item.Product.UserID) {
+			sellerIDs = append(sellerIDs, item.Product.UserID)
+		}
+	}
+	return sellerIDs
+}
+
+// notificationData - variabel template; untuk seller hanya nama produk miliknya
+func notificationData(event Event, sellerID string) map[string]string {
+	data := map[string]string{
+		"Note": event.Note,
+	}
+
+	// Event listing (review produk) tidak punya order
+	if event.Order != nil {
+		var names []string
+		for _, item := range event.Order.OrderItems {
+			if sellerID == "" || item.Product.UserID == sellerID {
+				names = append(names, item.Product.Name)
+			}
+		}
+
+		data["OrderRef"] = strings.ToUpper(event.Order.ID[:8])
+		data["Amount"] = formatRupiah(event.Order.TotalAmount)
+		data["ProductNames"] = strings.Join(names, ", ")
+	}
+
+	if event.Amount > 0 {
+		data["RefundAmount"] = formatRupiah(event.Amount)
+	}
+
+	if event.Product != nil {
+		data["ProductName"] = event.Product.Name
+		data["ProductPrice"] = formatRupiah(event.Product.Price)
+	}
+
+	return data
+}
+
+// formatRupiah - format angka ke "Rp 7.500.000"
+func formatRupiah(amount float64) string {
+	digits := fmt.Sprintf("%.0f", amount)
+
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	if negative {
+		return "-Rp " + grouped.String()
+	}
+	return "Rp " + grouped.String()
+}
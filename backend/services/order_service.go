@@ -1,17 +1,27 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"sk8consign-backend/config"
 	"sk8consign-backend/database"
 	"sk8consign-backend/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrProductConflict - produk keburu di-checkout buyer lain
+var ErrProductConflict = errors.New("some products were just purchased by another buyer")
+
+// CreateOrder - checkout isi cart. Baris produk dikunci (SELECT ... FOR UPDATE)
+// dan diupdate bersyarat dengan version, sehingga dua buyer yang checkout item
+// unik yang sama bersamaan hanya menghasilkan satu order.
 func CreateOrder(userID, paymentMethod, shippingAddr, notes string) (*models.Order, error) {
 	var carts []models.Cart
-	err := database.DB.Where("user_id = ?", userID).Preload("Product").Find(&carts).Error
+	err := database.DB.Where("user_id = ?", userID).Order("product_id").Find(&carts).Error
 	if err != nil {
 		return nil, err
 	}
@@ -20,39 +30,65 @@ func CreateOrder(userID, paymentMethod, shippingAddr, notes string) (*models.Ord
 		return nil, errors.New("cart is empty")
 	}
 
-	var totalAmount float64
-	var orderItems []models.OrderItem
-
-	for _, cart := range carts {
-		if !cart.Product.IsActive || cart.Product.Status != "available" {
-			return nil, errors.New("some products are not available")
-		}
-
-		subtotal := cart.Product.Price * float64(cart.Quantity)
-		totalAmount += subtotal
-
-		orderItem := models.OrderItem{
-			ID:        uuid.New().String(),
-			ProductID: cart.ProductID,
-			Quantity:  cart.Quantity,
-			Price:     cart.Product.Price,
-			Subtotal:  subtotal,
-		}
-		orderItems = append(orderItems, orderItem)
+	productIDs := make([]string, len(carts))
+	for i, cart := range carts {
+		productIDs[i] = cart.ProductID
 	}
 
+	paymentDueAt := time.Now().Add(config.AppConfig.ReservationTTL)
+
 	order := &models.Order{
 		ID:            uuid.New().String(),
 		UserID:        userID,
-		TotalAmount:   totalAmount,
-		Status:        "pending",
+		Status:        OrderStatusPending,
 		PaymentMethod: paymentMethod,
 		PaymentStatus: "pending",
 		ShippingAddr:  shippingAddr,
 		Notes:         notes,
+		PaymentDueAt:  &paymentDueAt,
 	}
 
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris produk dengan urutan ID yang konsisten untuk menghindari deadlock
+		var products []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", productIDs).
+			Order("id").
+			Find(&products).Error; err != nil {
+			return err
+		}
+
+		productByID := make(map[string]models.Product, len(products))
+		for _, product := range products {
+			productByID[product.ID] = product
+		}
+
+		var orderItems []models.OrderItem
+		for _, cart := range carts {
+			product, ok := productByID[cart.ProductID]
+			if !ok || !product.IsActive {
+				return errors.New("some products are not available")
+			}
+			if product.Status != "available" {
+				return ErrProductConflict
+			}
+
+			if err := checkConsignmentTerms(tx, product); err != nil {
+				return err
+			}
+
+			subtotal := product.Price * float64(cart.Quantity)
+			order.TotalAmount += subtotal
+
+			orderItems = append(orderItems, models.OrderItem{
+				ID:        uuid.New().String(),
+				ProductID: product.ID,
+				Quantity:  cart.Quantity,
+				Price:     product.Price,
+				Subtotal:  subtotal,
+			})
+		}
+
 		if err := tx.Create(order).Error; err != nil {
 			return err
 		}
@@ -63,9 +99,30 @@ func CreateOrder(userID, paymentMethod, shippingAddr, notes string) (*models.Ord
 				return err
 			}
 
-			if err := tx.Model(&models.Product{}).
-				Where("id = ?", orderItems[i].ProductID).
-				Update("status", "reserved").Error; err != nil {
+			// Update bersyarat: gagal jika status / version berubah sejak dibaca
+			product := productByID[orderItems[i].ProductID]
+			result := tx.Model(&models.Product{}).
+				Where("id = ? AND status = ? AND version = ?", product.ID, "available", product.Version).
+				Updates(map[string]interface{}{
+					"status":  "reserved",
+					"version": gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrProductConflict
+			}
+
+			reservation := models.Reservation{
+				ID:        uuid.New().String(),
+				OrderID:   order.ID,
+				ProductID: orderItems[i].ProductID,
+				UserID:    userID,
+				Status:    "active",
+				ExpiresAt: paymentDueAt,
+			}
+			if err := tx.Create(&reservation).Error; err != nil {
 				return err
 			}
 		}
@@ -74,6 +131,10 @@ func CreateOrder(userID, paymentMethod, shippingAddr, notes string) (*models.Ord
 			return err
 		}
 
+		if err := recordOrderHistory(tx, order.ID, "", OrderStatusPending, Actor{UserID: userID, Role: ActorBuyer}, "order created"); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
@@ -81,7 +142,9 @@ func CreateOrder(userID, paymentMethod, shippingAddr, notes string) (*models.Ord
 		return nil, err
 	}
 
-	database.DB.Preload("OrderItems.Product.User").First(order, order.ID)
+	publishOrderStatusEvent(order.ID, OrderStatusPending, "")
+
+	database.DB.Preload("OrderItems.Product.User").First(order, "id = ?", order.ID)
 	return order, nil
 }
 
@@ -110,6 +173,9 @@ func GetOrderByID(orderID, userID string) (*models.Order, error) {
 	var order models.Order
 	err := database.DB.Where("id = ? AND user_id = ?", orderID, userID).
 		Preload("OrderItems.Product.User").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
 		First(&order).Error
 
 	if err != nil {
@@ -119,70 +185,29 @@ func GetOrderByID(orderID, userID string) (*models.Order, error) {
 	return &order, nil
 }
 
-func UpdateOrderStatus(orderID, userID, status string) error {
-	validStatuses := []string{"pending", "confirmed", "shipped", "delivered", "cancelled"}
-	valid := false
-	for _, s := range validStatuses {
-		if s == status {
-			valid = true
-			break
-		}
-	}
-
-	if !valid {
-		return errors.New("invalid status")
-	}
-
-	result := database.DB.Model(&models.Order{}).
-		Where("id = ? AND user_id = ?", orderID, userID).
-		Update("status", status)
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("order not found")
-	}
-
-	return nil
-}
-
-func UpdatePaymentStatus(orderID, userID, paymentStatus string) error {
+func UpdateOrderStatus(ctx context.Context, actor AuditActor, orderID, status, note string) error {
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		var order models.Order
-		if err := tx.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if err := tx.Where("id = ?", orderID).First(&order).Error; err != nil {
 			return errors.New("order not found")
 		}
 
-		if err := tx.Model(&order).Update("payment_status", paymentStatus).Error; err != nil {
+		transitionActor, err := resolveOrderActor(&order, actor)
+		if err != nil {
 			return err
 		}
 
-		if paymentStatus == "paid" {
-			if err := tx.Model(&order).Update("status", "confirmed").Error; err != nil {
-				return err
-			}
-
-			var orderItems []models.OrderItem
-			if err := tx.Where("order_id = ?", orderID).Find(&orderItems).Error; err != nil {
-				return err
-			}
-
-			for _, item := range orderItems {
-				if err := tx.Model(&models.Product{}).
-					Where("id = ?", item.ProductID).
-					Update("status", "sold").Error; err != nil {
-					return err
-				}
-			}
-		}
-
-		return nil
+		return TransitionOrder(tx, &order, status, transitionActor, note)
 	})
 
-	return err
-}
-
+	if err != nil {
+		return err
+	}
 
+	if status == OrderStatusCancelled {
+		sendPendingRefunds(ctx, orderID)
+	}
 
+	publishOrderStatusEvent(orderID, status, note)
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+
+	"github.com/google/uuid"
+)
+
+// Dua buyer checkout produk unik yang sama bersamaan: hanya satu order yang jadi
+func TestCreateOrderConcurrentCheckoutOnlyOneWins(t *testing.T) {
+	setupTestDB(t)
+
+	seller := createTestUser(t, rbac.RoleConsignor)
+	product := createTestProduct(t, seller, 1500000)
+
+	buyers := []*models.User{createTestUser(t, rbac.RoleBuyer), createTestUser(t, rbac.RoleBuyer)}
+	for _, buyer := range buyers {
+		cart := models.Cart{ID: uuid.New().String(), UserID: buyer.ID, ProductID: product.ID, Quantity: 1}
+		if err := database.DB.Create(&cart).Error; err != nil {
+			t.Fatalf("create cart: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, len(buyers))
+
+	for i, buyer := range buyers {
+		wg.Add(1)
+		go func(i int, buyerID string) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = CreateOrder(buyerID, "bank_transfer", "Jl. Test 1", "")
+		}(i, buyer.ID)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded, conflicted := 0, 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrProductConflict):
+			conflicted++
+		default:
+			t.Errorf("unexpected error from buyer %d: %v", i, err)
+		}
+	}
+	if succeeded != 1 || conflicted != 1 {
+		t.Fatalf("want 1 success and 1 ErrProductConflict, got %d successes and %d conflicts (errors: %v)", succeeded, conflicted, errs)
+	}
+
+	var stored models.Product
+	if err := database.DB.First(&stored, "id = ?", product.ID).Error; err != nil {
+		t.Fatalf("reload product: %v", err)
+	}
+	if stored.Status != ProductStatusReserved {
+		t.Errorf("product status = %q, want %q", stored.Status, ProductStatusReserved)
+	}
+	if stored.Version != product.Version+1 {
+		t.Errorf("product version = %d, want %d (reserved exactly once)", stored.Version, product.Version+1)
+	}
+
+	var reservations, items int64
+	database.DB.Model(&models.Reservation{}).Where("product_id = ? AND status = ?", product.ID, "active").Count(&reservations)
+	database.DB.Model(&models.OrderItem{}).Where("product_id = ?", product.ID).Count(&items)
+	if reservations != 1 {
+		t.Errorf("active reservations = %d, want 1", reservations)
+	}
+	if items != 1 {
+		t.Errorf("order items for product = %d, want 1", items)
+	}
+}
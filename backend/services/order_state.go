@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sk8consign-backend/ledger"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status order
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+// Peran actor yang menjalankan transisi
+const (
+	ActorBuyer  = "buyer"
+	ActorSeller = "seller"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+// Actor - siapa yang menjalankan transisi status order
+type Actor struct {
+	UserID string
+	Role   string
+
+	// Asal request untuk audit log; kosong untuk transisi otomatis
+	IPAddress string
+	UserAgent string
+}
+
+// orderActor - actor transisi order dari identitas request
+func orderActor(actor AuditActor, role string) Actor {
+	return Actor{UserID: actor.UserID, Role: role, IPAddress: actor.IPAddress, UserAgent: actor.UserAgent}
+}
+
+// auditActor - actor audit log untuk transisi ini (role = peran terhadap order)
+func (a Actor) auditActor() AuditActor {
+	return AuditActor{UserID: a.UserID, Role: a.Role, IPAddress: a.IPAddress, UserAgent: a.UserAgent}
+}
+
+// SystemActor - actor untuk transisi otomatis (payment, worker, dll)
+var SystemActor = Actor{Role: ActorSystem}
+
+// orderTransition - aturan satu transisi: siapa yang boleh, guard tambahan, dan side effect
+type orderTransition struct {
+	actors []string
+	guard  func(tx *gorm.DB, order *models.Order) error
+	effect func(tx *gorm.DB, order *models.Order) error
+}
+
+// orderTransitions - state machine order: from -> to -> aturan
+var orderTransitions = map[string]map[string]orderTransition{
+	OrderStatusPending: {
+		OrderStatusConfirmed: {actors: []string{ActorSystem}, guard: requirePaid, effect: settleOrder},
+		OrderStatusCancelled: {actors: []string{ActorBuyer, ActorSeller, ActorAdmin, ActorSystem}, effect: cancelOrder},
+	},
+	OrderStatusConfirmed: {
+		OrderStatusShipped:   {actors: []string{ActorSeller, ActorAdmin}},
+		OrderStatusCancelled: {actors: []string{ActorSeller, ActorAdmin}, effect: cancelOrder},
+	},
+	OrderStatusShipped: {
+		OrderStatusDelivered: {actors: []string{ActorBuyer, ActorAdmin}},
+		OrderStatusRefunded:  {actors: []string{ActorAdmin}, guard: requireFullyRefunded},
+	},
+	OrderStatusDelivered: {
+		OrderStatusCompleted: {actors: []string{ActorBuyer, ActorAdmin, ActorSystem}},
+		OrderStatusRefunded:  {actors: []string{ActorSeller, ActorAdmin}, guard: requireFullyRefunded},
+	},
+	OrderStatusCompleted: {
+		OrderStatusRefunded: {actors: []string{ActorSeller, ActorAdmin}, guard: requireFullyRefunded},
+	},
+}
+
+// IsValidOrderStatus - cek apakah status dikenal state machine
+func IsValidOrderStatus(status string) bool {
+	if _, ok := orderTransitions[status]; ok {
+		return true
+	}
+	return status == OrderStatusCancelled || status == OrderStatusRefunded
+}
+
+// TransitionOrder - pindahkan order ke status baru jika transisi & actor diizinkan,
+// jalankan side effect, dan catat ke order_status_history. Harus dipanggil di dalam transaksi.
+func TransitionOrder(tx *gorm.DB, order *models.Order, to string, actor Actor, note string) error {
+	if !IsValidOrderStatus(to) {
+		return errors.New("invalid status")
+	}
+
+	from := order.Status
+	rule, ok := orderTransitions[from][to]
+	if !ok {
+		return fmt.Errorf("cannot change order status from %s to %s", from, to)
+	}
+
+	if !containsString(rule.actors, actor.Role) {
+		return fmt.Errorf("%s is not allowed to change order status from %s to %s", actor.Role, from, to)
+	}
+
+	if rule.guard != nil {
+		if err := rule.guard(tx, order); err != nil {
+			return err
+		}
+	}
+
+	// Update bersyarat supaya dua transisi paralel tidak saling menimpa
+	result := tx.Model(&models.Order{}).
+		Where("id = ? AND status = ?", order.ID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("order status was changed by another request")
+	}
+	order.Status = to
+
+	if rule.effect != nil {
+		if err := rule.effect(tx, order); err != nil {
+			return err
+		}
+	}
+
+	if err := recordOrderHistory(tx, order.ID, from, to, actor, note); err != nil {
+		return err
+	}
+
+	return RecordAuditChange(tx, actor.auditActor(), AuditOrderStatus, "order", order.ID,
+		map[string]interface{}{"status": from},
+		map[string]interface{}{"status": to},
+	)
+}
+
+// recordPaymentStatusChange - audit perubahan payment_status order
+func recordPaymentStatusChange(tx *gorm.DB, order *models.Order, from, to string, actor Actor) error {
+	return RecordAuditChange(tx, actor.auditActor(), AuditOrderPaymentStatus, "order", order.ID,
+		map[string]interface{}{"payment_status": from},
+		map[string]interface{}{"payment_status": to},
+	)
+}
+
+// recordOrderHistory - simpan satu baris order_status_history
+func recordOrderHistory(tx *gorm.DB, orderID, from, to string, actor Actor, note string) error {
+	var actorID *string
+	if actor.UserID != "" {
+		id := actor.UserID
+		actorID = &id
+	}
+
+	history := models.OrderStatusHistory{
+		ID:         uuid.New().String(),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		ActorRole:  actor.Role,
+		Note:       note,
+	}
+
+	return tx.Create(&history).Error
+}
+
+// resolveOrderActor - tentukan peran user terhadap order
+func resolveOrderActor(order *models.Order, actor AuditActor) (Actor, error) {
+	userID := actor.UserID
+	if rbac.Can(actor.Role, rbac.PermOrderManage) {
+		return orderActor(actor, ActorAdmin), nil
+	}
+
+	if order.UserID == userID {
+		return orderActor(actor, ActorBuyer), nil
+	}
+
+	return Actor{}, errors.New("order not found")
+}
+
+// requirePaid - guard: order hanya bisa dikonfirmasi setelah dibayar
+func requirePaid(tx *gorm.DB, order *models.Order) error {
+	if order.PaymentStatus != "paid" {
+		return errors.New("order has not been paid")
+	}
+	return nil
+}
+
+// requireFullyRefunded - guard: order hanya "refunded" setelah seluruh dana dikembalikan
+// (lewat RefundOrder / ReviewReturnRequest), produk di-relist per item retur
+func requireFullyRefunded(tx *gorm.DB, order *models.Order) error {
+	if order.PaymentStatus != "refunded" {
+		return errors.New("order has not been fully refunded")
+	}
+	return nil
+}
+
+// settleOrder - side effect konfirmasi: produk terjual, payout consignor & jurnal ledger
+func settleOrder(tx *gorm.DB, order *models.Order) error {
+	var orderItems []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+		return err
+	}
+
+	for _, item := range orderItems {
+		if err := tx.Model(&models.Product{}).
+			Where("id = ?", item.ProductID).
+			Updates(map[string]interface{}{
+				"status":  "sold",
+				"version": gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.Reservation{}).
+		Where("order_id = ? AND status = ?", order.ID, "active").
+		Update("status", "converted").Error; err != nil {
+		return err
+	}
+
+	payouts, err := recordConsignorPayouts(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	proceeds := proceedsFromPayouts(payouts)
+	if _, err := ledger.PostPayment(tx, order.ID, order.UserID, order.TotalAmount, proceeds); err != nil {
+		return err
+	}
+	if _, err := ledger.PostCommission(tx, order.ID, proceeds); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// cancelOrder - side effect cancel: produk dilepas, item yang belum dikirim dibatalkan,
+// dan order yang sudah dibayar di-refund penuh
+func cancelOrder(tx *gorm.DB, order *models.Order) error {
+	if err := releaseOrderProducts(tx, order); err != nil {
+		return err
+	}
+
+	if order.PaymentStatus == "paid" {
+		if err := refundCancelledOrder(tx, order); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.Reservation{}).
+		Where("order_id = ? AND status = ?", order.ID, "active").
+		Updates(map[string]interface{}{
+			"status":      "released",
+			"released_at": &now,
+		}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.OrderItem{}).
+		Where("order_id = ? AND fulfillment_status = ?", order.ID, "pending").
+		Update("fulfillment_status", "cancelled").Error
+}
+
+// releaseOrderProducts - side effect cancel: produk kembali available
+func releaseOrderProducts(tx *gorm.DB, order *models.Order) error {
+	var orderItems []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+		return err
+	}
+
+	for _, item := range orderItems {
+		if err := tx.Model(&models.Product{}).
+			Where("id = ? AND status IN ?", item.ProductID, []string{"reserved", "sold"}).
+			Updates(map[string]interface{}{
+				"status":  "available",
+				"version": gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
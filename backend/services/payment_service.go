@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/payment"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentIntentTTL - masa berlaku maksimum instruksi pembayaran dari provider;
+// tidak pernah melewati batas bayar order (PaymentDueAt)
+const paymentIntentTTL = 24 * time.Hour
+
+var paymentProvider payment.PaymentProvider
+
+// SetPaymentProvider - pasang payment provider yang dipakai services
+func SetPaymentProvider(provider payment.PaymentProvider) {
+	paymentProvider = provider
+}
+
+// GetPaymentProvider - provider aktif (nil jika belum di-setup)
+func GetPaymentProvider() payment.PaymentProvider {
+	return paymentProvider
+}
+
+// CreatePaymentIntent - minta instruksi pembayaran (redirect / VA) untuk order buyer.
+// Intent pending yang masih berlaku dipakai ulang.
+func CreatePaymentIntent(ctx context.Context, orderID, userID, method string) (*models.PaymentIntent, error) {
+	if paymentProvider == nil {
+		return nil, errors.New("payment provider is not configured")
+	}
+
+	var order models.Order
+	if err := database.DB.Preload("User").Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if order.Status != OrderStatusPending || order.PaymentStatus == "paid" {
+		return nil, errors.New("order is not awaiting payment")
+	}
+
+	if method == "" {
+		method = order.PaymentMethod
+	}
+
+	var existing models.PaymentIntent
+	err := database.DB.Where("order_id = ? AND provider = ? AND method = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)",
+		order.ID, paymentProvider.Name(), method, payment.StatusPending, time.Now()).
+		Order("created_at DESC").
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+
+	// Pembayaran setelah PaymentDueAt akan ditolak karena reservation worker sudah
+	// membatalkan order, jadi instruksi pembayaran harus kedaluwarsa lebih dulu
+	expiresAt := time.Now().Add(paymentIntentTTL)
+	if order.PaymentDueAt != nil {
+		if !order.PaymentDueAt.After(time.Now()) {
+			return nil, errors.New("payment deadline for this order has passed")
+		}
+		if order.PaymentDueAt.Before(expiresAt) {
+			expiresAt = *order.PaymentDueAt
+		}
+	}
+
+	// Intent baru untuk order yang sama (setelah kedaluwarsa / ganti metode) harus
+	// memakai key berbeda, kalau tidak gateway mengembalikan intent yang lama
+	var attempts int64
+	if err := database.DB.Model(&models.PaymentIntent{}).Where("order_id = ?", order.ID).Count(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	intent, err := paymentProvider.CreateIntent(ctx, payment.IntentRequest{
+		OrderID:        order.ID,
+		IdempotencyKey: fmt.Sprintf("%s-%d", order.ID, attempts+1),
+		Amount:         order.TotalAmount,
+		Method:         method,
+		CustomerName:   order.User.FullName,
+		CustomerEmail:  order.User.Email,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		log.Printf("❌ Payment intent failed for order %s: %v", order.ID, err)
+		return nil, errors.New("failed to create payment with provider")
+	}
+
+	if intent.ExpiresAt == nil || intent.ExpiresAt.After(expiresAt) {
+		intent.ExpiresAt = &expiresAt
+	}
+
+	paymentIntent := &models.PaymentIntent{
+		ID:          uuid.New().String(),
+		OrderID:     order.ID,
+		UserID:      userID,
+		Provider:    paymentProvider.Name(),
+		Reference:   intent.Reference,
+		Method:      method,
+		Amount:      order.TotalAmount,
+		Status:      payment.StatusPending,
+		RedirectURL: intent.RedirectURL,
+		VANumber:    intent.VANumber,
+		Bank:        intent.Bank,
+		ExpiresAt:   intent.ExpiresAt,
+	}
+
+	if err := database.DB.Create(paymentIntent).Error; err != nil {
+		return nil, err
+	}
+
+	return paymentIntent, nil
+}
+
+// HandlePaymentWebhook - verifikasi dan proses webhook provider. Satu-satunya
+// jalur yang boleh mengubah payment_status menjadi "paid". Idempotent.
+// Pembayaran untuk order yang sudah tidak menunggu pembayaran (mis. dibatalkan
+// reservation worker) tetap dicatat lalu dikembalikan otomatis.
+func HandlePaymentWebhook(ctx context.Context, header http.Header, body []byte) error {
+	if paymentProvider == nil {
+		return errors.New("payment provider is not configured")
+	}
+
+	event, err := paymentProvider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	confirmed, late := false, false
+	var orderID, intentID string
+	var lateAmount float64
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var intent models.PaymentIntent
+		if err := tx.Where("provider = ? AND reference = ?", paymentProvider.Name(), event.Reference).First(&intent).Error; err != nil {
+			return errors.New("payment intent not found")
+		}
+		orderID, intentID = intent.OrderID, intent.ID
+
+		if event.OrderID != "" && event.OrderID != intent.OrderID {
+			return errors.New("webhook order does not match payment intent")
+		}
+
+		// Webhook duplikat / terlambat untuk intent yang sudah final
+		if intent.Status == payment.StatusPaid {
+			return nil
+		}
+
+		switch event.Status {
+		case payment.StatusPaid:
+			if math.Abs(event.Amount-intent.Amount) >= 0.01 {
+				return fmt.Errorf("paid amount %.2f does not match expected %.2f", event.Amount, intent.Amount)
+			}
+
+			var order models.Order
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", intent.OrderID).First(&order).Error; err != nil {
+				return errors.New("order not found")
+			}
+
+			// Dana sudah ditangkap provider: selalu dicatat, walau order tidak bisa dikonfirmasi
+			now := time.Now()
+			updates := map[string]interface{}{
+				"status":  payment.StatusPaid,
+				"paid_at": &now,
+			}
+			late = order.Status != OrderStatusPending || order.PaymentStatus == "paid"
+			if late {
+				updates["refund_status"] = RefundStatusPending
+				lateAmount = intent.Amount
+			}
+			if err := tx.Model(&intent).Updates(updates).Error; err != nil {
+				return err
+			}
+
+			if late {
+				log.Printf("⚠️  Late payment %s for order %s (status %s), refunding", intent.Reference, order.ID, order.Status)
+				return nil
+			}
+
+			if err := confirmOrderPayment(tx, intent.OrderID, intent.Reference); err != nil {
+				return err
+			}
+			confirmed = true
+
+		case payment.StatusFailed, payment.StatusExpired:
+			if err := tx.Model(&intent).Update("status", event.Status).Error; err != nil {
+				return err
+			}
+
+		case payment.StatusPending:
+			// tidak ada perubahan
+
+		default:
+			return fmt.Errorf("unknown payment status %q", event.Status)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if confirmed {
+		publishOrderStatusEvent(orderID, OrderStatusConfirmed, "")
+	}
+
+	if late {
+		refundLatePayments(ctx, intentID)
+		publishRefundEvent(orderID, EventLatePayment, lateAmount, "")
+	}
+
+	return nil
+}
+
+// refundLatePayments - kembalikan pembayaran terlambat (refund_status "pending") lewat
+// provider; intentID kosong = semua. Yang gagal dicoba ulang oleh RunRefundWorker.
+// Return jumlah yang berhasil.
+func refundLatePayments(ctx context.Context, intentID string) int {
+	var intents []models.PaymentIntent
+	query := database.DB.WithContext(ctx).Where("refund_status = ?", RefundStatusPending)
+	if intentID != "" {
+		query = query.Where("id = ?", intentID)
+	}
+	if err := query.Find(&intents).Error; err != nil {
+		log.Printf("❌ Failed to load late payments: %v", err)
+		return 0
+	}
+
+	refunded := 0
+	for _, intent := range intents {
+		if paymentProvider == nil || paymentProvider.Name() != intent.Provider {
+			log.Printf("❌ Late payment %s: payment provider %s is not available for refunds", intent.Reference, intent.Provider)
+			continue
+		}
+
+		reference, err := paymentProvider.Refund(ctx, payment.RefundRequest{
+			Reference:      intent.Reference,
+			IdempotencyKey: "late-" + intent.ID,
+			Amount:         intent.Amount,
+			Reason:         "payment received after the order stopped awaiting payment",
+		})
+		if err != nil {
+			log.Printf("❌ Refund of late payment %s failed: %v", intent.Reference, err)
+			continue
+		}
+
+		if err := database.DB.Model(&models.PaymentIntent{}).
+			Where("id = ? AND refund_status = ?", intent.ID, RefundStatusPending).
+			Updates(map[string]interface{}{
+				"refund_status":    "refunded",
+				"refund_reference": reference,
+			}).Error; err != nil {
+			log.Printf("❌ Failed to record refund of late payment %s: %v", intent.Reference, err)
+			continue
+		}
+		refunded++
+	}
+
+	return refunded
+}
+
+// confirmOrderPayment - tandai order paid lalu konfirmasi lewat state machine
+func confirmOrderPayment(tx *gorm.DB, orderID, reference string) error {
+	var order models.Order
+	if err := tx.Where("id = ?", orderID).First(&order).Error; err != nil {
+		return errors.New("order not found")
+	}
+
+	if order.PaymentStatus == "paid" {
+		return nil
+	}
+
+	if err := tx.Model(&order).Update("payment_status", "paid").Error; err != nil {
+		return err
+	}
+	if err := recordPaymentStatusChange(tx, &order, order.PaymentStatus, "paid", SystemActor); err != nil {
+		return err
+	}
+	order.PaymentStatus = "paid"
+
+	return TransitionOrder(tx, &order, OrderStatusConfirmed, SystemActor, "payment received ("+reference+")")
+}
+
+// SimulateFakePayment - development only: kirim webhook bertanda tangan dari fake provider
+func SimulateFakePayment(ctx context.Context, reference, status string) error {
+	fake, ok := paymentProvider.(*payment.FakeProvider)
+	if !ok {
+		return errors.New("fake payment provider is not active")
+	}
+
+	var intent models.PaymentIntent
+	if err := database.DB.Where("provider = ? AND reference = ?", fake.Name(), reference).First(&intent).Error; err != nil {
+		return errors.New("payment intent not found")
+	}
+
+	if status == "" {
+		status = payment.StatusPaid
+	}
+
+	body, header, err := fake.SignedWebhook(payment.WebhookEvent{
+		Reference: intent.Reference,
+		OrderID:   intent.OrderID,
+		Status:    status,
+		Amount:    intent.Amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	return HandlePaymentWebhook(ctx, header, body)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/payment"
+	"sk8consign-backend/rbac"
+)
+
+// Webhook "paid" yang datang setelah reservation worker membatalkan order tetap
+// diterima (2xx), pembayarannya dicatat, lalu dikembalikan otomatis
+func TestPaymentWebhookAfterExpiryRefundsLatePayment(t *testing.T) {
+	setupTestDB(t)
+	fake := useFakePaymentProvider(t)
+
+	seller := createTestUser(t, rbac.RoleConsignor)
+	buyer := createTestUser(t, rbac.RoleBuyer)
+	product := createTestProduct(t, seller, 750000)
+	order := createTestOrder(t, buyer, product)
+
+	intent, err := CreatePaymentIntent(context.Background(), order.ID, buyer.ID, "")
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	cancelled, err := expireOrder(order.ID, intent.CreatedAt)
+	if err != nil || !cancelled {
+		t.Fatalf("expireOrder = %v, %v; want the order cancelled", cancelled, err)
+	}
+
+	// Provider mengirim ulang webhook sampai mendapat 2xx; keduanya harus diterima
+	for i := 0; i < 2; i++ {
+		if err := deliverPaymentWebhook(fake, intent, payment.StatusPaid); err != nil {
+			t.Fatalf("HandlePaymentWebhook (delivery %d) = %v, want nil", i+1, err)
+		}
+	}
+
+	var stored models.PaymentIntent
+	if err := database.DB.First(&stored, "id = ?", intent.ID).Error; err != nil {
+		t.Fatalf("reload intent: %v", err)
+	}
+	if stored.Status != payment.StatusPaid || stored.PaidAt == nil {
+		t.Errorf("intent status = %q (paid_at %v), want the captured payment recorded as paid", stored.Status, stored.PaidAt)
+	}
+	if stored.RefundStatus != "refunded" || stored.RefundReference == "" {
+		t.Errorf("intent refund = %q / %q, want refunded with a provider reference", stored.RefundStatus, stored.RefundReference)
+	}
+
+	var storedOrder models.Order
+	if err := database.DB.First(&storedOrder, "id = ?", order.ID).Error; err != nil {
+		t.Fatalf("reload order: %v", err)
+	}
+	if storedOrder.Status != OrderStatusCancelled || storedOrder.PaymentStatus == "paid" {
+		t.Errorf("order = %s / %s, want it to stay cancelled and unpaid", storedOrder.Status, storedOrder.PaymentStatus)
+	}
+
+	var storedProduct models.Product
+	if err := database.DB.First(&storedProduct, "id = ?", product.ID).Error; err != nil {
+		t.Fatalf("reload product: %v", err)
+	}
+	if storedProduct.Status != ProductStatusAvailable {
+		t.Errorf("product status = %q, want %q", storedProduct.Status, ProductStatusAvailable)
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Nama facet pencarian katalog
+const (
+	FacetCategory  = "category"
+	FacetCondition = "condition"
+	FacetPrice     = "price"
+)
+
+// ErrInvalidPriceBucket - key price bucket tidak dikenal
+var ErrInvalidPriceBucket = errors.New("invalid price bucket")
+
+// PriceBucket - rentang harga [Min, Max) untuk facet dan filter; Max 0 = tanpa batas atas
+type PriceBucket struct {
+	Key string  `json:"key"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max,omitempty"`
+}
+
+// priceBuckets - rentang harga (Rupiah), berurutan dan bersambung
+var priceBuckets = []PriceBucket{
+	{Key: "under_1m", Min: 0, Max: 1_000_000},
+	{Key: "1m_5m", Min: 1_000_000, Max: 5_000_000},
+	{Key: "5m_10m", Min: 5_000_000, Max: 10_000_000},
+	{Key: "10m_25m", Min: 10_000_000, Max: 25_000_000},
+	{Key: "25m_plus", Min: 25_000_000},
+}
+
+// FacetCount - jumlah produk untuk satu nilai facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucketCount - jumlah produk di satu rentang harga
+type PriceBucketCount struct {
+	PriceBucket
+	Count int64 `json:"count"`
+}
+
+// ProductFacets - hitungan per facet untuk sidebar filter. Setiap facet dihitung
+// dengan semua filter aktif kecuali filternya sendiri.
+type ProductFacets struct {
+	Categories   []FacetCount       `json:"category"`
+	Conditions   []FacetCount       `json:"condition"`
+	PriceBuckets []PriceBucketCount `json:"price"`
+}
+
+// productFacets - hitung facet category, condition, dan price bucket
+func productFacets(ctx context.Context, params ProductSearchParams, matchedIDs []string) (*ProductFacets, error) {
+	facets := emptyProductFacets()
+
+	base := func(except string) *gorm.DB {
+		return applyProductFilters(database.DB.WithContext(ctx).Model(&models.Product{}), params, matchedIDs, except)
+	}
+
+	if err := base(FacetCategory).
+		Select("category AS value, COUNT(*) AS count").
+		Group("category").
+		Order("count DESC, value ASC").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	if err := base(FacetCondition).
+		Select("`condition` AS value, COUNT(*) AS count").
+		Group("`condition`").
+		Order("count DESC, value ASC").
+		Scan(&facets.Conditions).Error; err != nil {
+		return nil, err
+	}
+
+	var buckets []FacetCount
+	caseSQL, caseArgs := priceBucketCase()
+	if err := base(FacetPrice).
+		Select(caseSQL+" AS value, COUNT(*) AS count", caseArgs...).
+		Group("value").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		for i := range facets.PriceBuckets {
+			if facets.PriceBuckets[i].Key == bucket.Value {
+				facets.PriceBuckets[i].Count = bucket.Count
+			}
+		}
+	}
+
+	return &facets, nil
+}
+
+// emptyProductFacets - facet tanpa hasil; semua price bucket tetap ada dengan count 0
+func emptyProductFacets() ProductFacets {
+	facets := ProductFacets{
+		Categories:   []FacetCount{},
+		Conditions:   []FacetCount{},
+		PriceBuckets: make([]PriceBucketCount, 0, len(priceBuckets)),
+	}
+	for _, bucket := range priceBuckets {
+		facets.PriceBuckets = append(facets.PriceBuckets, PriceBucketCount{PriceBucket: bucket})
+	}
+	return facets
+}
+
+// priceBucketCase - ekspresi CASE yang memetakan price ke key bucket
+func priceBucketCase() (string, []interface{}) {
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(priceBuckets)*2)
+
+	sql.WriteString("CASE")
+	for _, bucket := range priceBuckets[:len(priceBuckets)-1] {
+		sql.WriteString(" WHEN price < ? THEN ?")
+		args = append(args, bucket.Max, bucket.Key)
+	}
+	sql.WriteString(" ELSE ? END")
+	args = append(args, priceBuckets[len(priceBuckets)-1].Key)
+
+	return sql.String(), args
+}
+
+// priceBucketCondition - filter OR atas beberapa price bucket (key sudah divalidasi)
+func priceBucketCondition(keys []string) *gorm.DB {
+	condition := database.DB.Session(&gorm.Session{NewDB: true})
+	for i, key := range keys {
+		bucket := findPriceBucket(key)
+
+		clause := database.DB.Session(&gorm.Session{NewDB: true}).Where("price >= ?", bucket.Min)
+		if bucket.Max > 0 {
+			clause = clause.Where("price < ?", bucket.Max)
+		}
+
+		if i == 0 {
+			condition = condition.Where(clause)
+		} else {
+			condition = condition.Or(clause)
+		}
+	}
+	return condition
+}
+
+func findPriceBucket(key string) *PriceBucket {
+	for i := range priceBuckets {
+		if priceBuckets[i].Key == key {
+			return &priceBuckets[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sk8consign-backend/database"
+	"sk8consign-backend/imageproc"
+	"sk8consign-backend/models"
+	"sk8consign-backend/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxProductImages = 10
+
+// Error upload yang dipetakan handler ke status HTTP tersendiri
+var (
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrUnsupportedImageType = errors.New("unsupported image type, must be JPEG, PNG, or WebP")
+)
+
+// allowedImageTypes - MIME hasil sniffing isi file yang diterima
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+var blobStore storage.BlobStore
+
+// maxImageBytes - batas ukuran satu file upload
+var maxImageBytes int64 = 15 << 20
+
+// SetBlobStore - pasang penyimpanan file (local / S3) dan batas ukuran upload
+func SetBlobStore(store storage.BlobStore, maxBytes int64) {
+	blobStore = store
+	if maxBytes > 0 {
+		maxImageBytes = maxBytes
+	}
+}
+
+// GetBlobStore - penyimpanan file yang aktif
+func GetBlobStore() storage.BlobStore {
+	return blobStore
+}
+
+// MaxImageBytes - batas ukuran satu file upload
+func MaxImageBytes() int64 {
+	return maxImageBytes
+}
+
+// galleryOrder - preload galeri produk sesuai urutan
+func galleryOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// AddProductImage - simpan satu foto ke galeri produk milik actor. Tipe file
+// ditentukan dari isinya (bukan header dari client). Foto diproses ke varian
+// thumbnail / medium / large (JPEG + WebP) tanpa metadata EXIF/GPS; file asli
+// tidak disimpan. Foto pertama jadi foto utama.
+func AddProductImage(ctx context.Context, actor AuditActor, productID string, file io.Reader) (*models.ProductImage, error) {
+	if blobStore == nil {
+		return nil, errors.New("file storage is not configured")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+	if len(data) == 0 {
+		return nil, errors.New("image file is empty")
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return nil, ErrUnsupportedImageType
+	}
+
+	product, err := loadEditableProduct(database.DB, actor, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxProductImages {
+		return nil, fmt.Errorf("a product can have at most %d images", maxProductImages)
+	}
+
+	processed, err := imageproc.Process(data)
+	if err != nil {
+		if errors.Is(err, imageproc.ErrImageTooLarge) {
+			return nil, ErrImageTooLarge
+		}
+		log.Printf("⚠️  Failed to process image for product %s: %v", product.ID, err)
+		return nil, errors.New("image file is corrupt or cannot be processed")
+	}
+
+	// Blob diunggah dulu; jika simpan ke DB gagal, blob dihapus lagi
+	imageID := uuid.New().String()
+	image := models.ProductImage{
+		ID:          imageID,
+		ProductID:   product.ID,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Width:       processed.Width,
+		Height:      processed.Height,
+		BlurHash:    processed.BlurHash,
+	}
+
+	for _, variant := range processed.Variants {
+		key := "products/" + product.ID + "/" + imageID + "/" + variant.Size + variant.Extension
+		if err := blobStore.Put(ctx, key, bytes.NewReader(variant.Data), int64(len(variant.Data)), variant.ContentType); err != nil {
+			log.Printf("❌ Failed to store image %s: %v", key, err)
+			deleteBlobs(image.StorageKeys())
+			return nil, errors.New("failed to store image")
+		}
+
+		stored := models.ImageVariant{
+			Size:      variant.Size,
+			Format:    variant.Format,
+			Key:       key,
+			URL:       blobStore.URL(key),
+			Width:     variant.Width,
+			Height:    variant.Height,
+			SizeBytes: len(variant.Data),
+		}
+		image.Variants = append(image.Variants, stored)
+
+		// URL utama (dan image_url produk) = JPEG terbesar, bisa dibuka semua client
+		if image.StorageKey == "" && variant.Format == imageproc.FormatJPEG {
+			image.StorageKey = stored.Key
+			image.URL = stored.URL
+		}
+	}
+
+	resubmitted := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var last struct {
+			Position int
+			Images   int64
+		}
+		if err := tx.Model(&models.ProductImage{}).
+			Select("COALESCE(MAX(position), -1) AS position, COUNT(*) AS images").
+			Where("product_id = ?", product.ID).
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		if last.Images >= maxProductImages {
+			return fmt.Errorf("a product can have at most %d images", maxProductImages)
+		}
+
+		image.Position = last.Position + 1
+		image.IsPrimary = last.Images == 0
+
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+
+		if image.IsPrimary {
+			if err := syncPrimaryImageURL(tx, product.ID, image.URL); err != nil {
+				return err
+			}
+		}
+
+		if resubmitted, err = resubmitForReview(tx, actor, product); err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditProductImageAdd, "product", product.ID, map[string]interface{}{
+			"image_id":   image.ID,
+			"size_bytes": image.SizeBytes,
+			"type":       image.ContentType,
+			"variants":   len(image.Variants),
+		})
+	})
+	if err != nil {
+		deleteBlobs(image.StorageKeys())
+		return nil, err
+	}
+
+	if resubmitted {
+		publishResubmittedProduct(product.ID)
+	}
+
+	return &image, nil
+}
+
+// DeleteProductImage - hapus foto dari galeri; jika foto utama dihapus,
+// foto berikutnya menjadi foto utama
+func DeleteProductImage(actor AuditActor, productID, imageID string) error {
+	var image models.ProductImage
+	resubmitted := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := loadEditableProduct(tx, actor, productID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND product_id = ?", imageID, product.ID).First(&image).Error; err != nil {
+			return errors.New("image not found")
+		}
+
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+
+		if image.IsPrimary {
+			var next models.ProductImage
+			err := tx.Where("product_id = ?", product.ID).Order("position ASC").First(&next).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := syncPrimaryImageURL(tx, product.ID, ""); err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			default:
+				if err := setPrimaryImage(tx, product.ID, &next); err != nil {
+					return err
+				}
+			}
+		}
+
+		if resubmitted, err = resubmitForReview(tx, actor, product); err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditProductImageRemove, "product", product.ID, map[string]interface{}{
+			"image_id": image.ID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteBlobs(image.StorageKeys())
+	if resubmitted {
+		publishResubmittedProduct(productID)
+	}
+	return nil
+}
+
+// SetPrimaryProductImage - jadikan satu foto sebagai foto utama produk
+func SetPrimaryProductImage(actor AuditActor, productID, imageID string) error {
+	resubmitted := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := loadEditableProduct(tx, actor, productID)
+		if err != nil {
+			return err
+		}
+
+		var image models.ProductImage
+		if err := tx.Where("id = ? AND product_id = ?", imageID, product.ID).First(&image).Error; err != nil {
+			return errors.New("image not found")
+		}
+
+		if image.IsPrimary {
+			return nil
+		}
+
+		if err := setPrimaryImage(tx, product.ID, &image); err != nil {
+			return err
+		}
+
+		resubmitted, err = resubmitForReview(tx, actor, product)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if resubmitted {
+		publishResubmittedProduct(productID)
+	}
+	return nil
+}
+
+// ReorderProductImages - atur ulang urutan galeri; imageIDs harus berisi semua foto produk
+func ReorderProductImages(actor AuditActor, productID string, imageIDs []string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := loadEditableProduct(tx, actor, productID)
+		if err != nil {
+			return err
+		}
+
+		var images []models.ProductImage
+		if err := tx.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+			return err
+		}
+
+		if len(imageIDs) != len(images) {
+			return errors.New("image_ids must list every image of the product exactly once")
+		}
+
+		existing := make(map[string]bool, len(images))
+		for _, image := range images {
+			existing[image.ID] = true
+		}
+		for position, id := range imageIDs {
+			if !existing[id] {
+				return errors.New("image_ids must list every image of the product exactly once")
+			}
+			delete(existing, id)
+
+			if err := tx.Model(&models.ProductImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadEditableProduct - produk milik actor yang galerinya boleh diubah
+// (bukan rejected, reserved, atau sold)
+func loadEditableProduct(tx *gorm.DB, actor AuditActor, productID string) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Where("id = ? AND user_id = ?", productID, actor.UserID).First(&product).Error; err != nil {
+		return nil, errors.New("product not found or unauthorized")
+	}
+
+	switch product.Status {
+	case ProductStatusRejected:
+		return nil, errors.New("rejected products cannot be edited, please submit a new listing")
+	case ProductStatusReserved, ProductStatusSold:
+		return nil, errors.New("reserved or sold products cannot be edited")
+	}
+
+	return &product, nil
+}
+
+// publishResubmittedProduct - perbarui index dan beri tahu pemilik setelah
+// perubahan foto mengembalikan listing ke antrean review
+func publishResubmittedProduct(productID string) {
+	var product models.Product
+	if err := database.DB.Preload("User").Preload("Images", galleryOrder).First(&product, "id = ?", productID).Error; err != nil {
+		log.Printf("⚠️  Failed to reload resubmitted product %s: %v", productID, err)
+		return
+	}
+
+	indexProduct(&product)
+	publishProductEvent(&product, EventProductSubmitted, "")
+}
+
+// setPrimaryImage - pindahkan flag foto utama dan sinkronkan image_url produk
+func setPrimaryImage(tx *gorm.DB, productID string, image *models.ProductImage) error {
+	if err := tx.Model(&models.ProductImage{}).
+		Where("product_id = ? AND id <> ?", productID, image.ID).
+		Update("is_primary", false).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(image).Update("is_primary", true).Error; err != nil {
+		return err
+	}
+
+	return syncPrimaryImageURL(tx, productID, image.URL)
+}
+
+// syncPrimaryImageURL - image_url produk tetap diisi foto utama untuk client lama
+func syncPrimaryImageURL(tx *gorm.DB, productID, url string) error {
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("image_url", url).Error
+}
+
+// deleteBlobs - hapus blob di luar transaksi; gagal hapus hanya meninggalkan file yatim
+func deleteBlobs(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := blobStore.Delete(ctx, key); err != nil {
+			log.Printf("⚠️  Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status produk
+const (
+	ProductStatusPendingReview    = "pending_review"
+	ProductStatusChangesRequested = "changes_requested"
+	ProductStatusRejected         = "rejected"
+	ProductStatusAvailable        = "available"
+	ProductStatusReserved         = "reserved"
+	ProductStatusSold             = "sold"
+)
+
+// Keputusan reviewer atas listing consignor
+const (
+	ReviewDecisionApprove        = "approve"
+	ReviewDecisionReject         = "reject"
+	ReviewDecisionRequestChanges = "request_changes"
+)
+
+// publicProductStatuses - status yang tampil di katalog publik; listing yang
+// belum lolos review hanya terlihat oleh pemiliknya dan reviewer
+var publicProductStatuses = []string{ProductStatusAvailable, ProductStatusReserved, ProductStatusSold}
+
+// productReviewOutcomes - status hasil, aksi audit, dan event notifikasi per keputusan
+var productReviewOutcomes = map[string]struct {
+	status string
+	action string
+	event  string
+}{
+	ReviewDecisionApprove:        {ProductStatusAvailable, AuditProductApprove, EventProductApproved},
+	ReviewDecisionReject:         {ProductStatusRejected, AuditProductReject, EventProductRejected},
+	ReviewDecisionRequestChanges: {ProductStatusChangesRequested, AuditProductRequestChanges, EventProductChangesRequested},
+}
+
+// GetProductReviewQueue - listing yang menunggu review, paling lama menunggu lebih dulu
+func GetProductReviewQueue(limit, offset int) ([]models.Product, int64, error) {
+	var products []models.Product
+	var total int64
+
+	db := database.DB.Model(&models.Product{}).Where("status = ?", ProductStatusPendingReview)
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Preload("User").Preload("Images", galleryOrder).
+		Order("updated_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&products).Error
+
+	return products, total, err
+}
+
+// ReviewProduct - admin / moderator menyetujui, menolak, atau meminta perubahan
+// listing. Reject dan request changes wajib menyertakan alasan untuk consignor.
+func ReviewProduct(actor AuditActor, productID, decision, note string) (*models.Product, error) {
+	outcome, ok := productReviewOutcomes[decision]
+	if !ok {
+		return nil, fmt.Errorf("invalid decision, must be one of: %s, %s, %s",
+			ReviewDecisionApprove, ReviewDecisionReject, ReviewDecisionRequestChanges)
+	}
+
+	note = strings.TrimSpace(note)
+	if decision != ReviewDecisionApprove && note == "" {
+		return nil, errors.New("a reason is required when rejecting or requesting changes")
+	}
+	if len(note) > 500 {
+		return nil, errors.New("review note is too long")
+	}
+
+	var product models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", productID).First(&product).Error; err != nil {
+			return errors.New("product not found")
+		}
+
+		if product.UserID == actor.UserID {
+			return errors.New("you cannot review your own listing")
+		}
+
+		before := map[string]interface{}{
+			"status":      product.Status,
+			"review_note": product.ReviewNote,
+		}
+
+		// Update bersyarat supaya dua reviewer tidak memutus listing yang sama
+		now := time.Now()
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND status = ?", product.ID, ProductStatusPendingReview).
+			Updates(map[string]interface{}{
+				"status":      outcome.status,
+				"review_note": note,
+				"reviewed_by": actor.UserID,
+				"reviewed_at": &now,
+				"version":     gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("product is not waiting for review")
+		}
+
+		if err := tx.Preload("User").Preload("Images", galleryOrder).First(&product, "id = ?", product.ID).Error; err != nil {
+			return err
+		}
+
+		return RecordAuditChange(tx, actor, outcome.action, "product", product.ID, before, map[string]interface{}{
+			"status":      product.Status,
+			"review_note": product.ReviewNote,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔎 Product %s reviewed (%s) by %s", product.ID, decision, actor.UserID)
+
+	publishProductEvent(&product, outcome.event, note)
+
+	return &product, nil
+}
+
+// nextOwnerStatus - status listing setelah diedit pemiliknya. Listing yang belum
+// lolos review selalu kembali ke antrean. Pemilik tidak bisa memilih status review
+// maupun reserved / sold (diatur checkout), dan listing reserved / sold terkunci.
+func nextOwnerStatus(current, requested string) (string, error) {
+	switch current {
+	case ProductStatusRejected:
+		return "", errors.New("rejected products cannot be edited, please submit a new listing")
+	case ProductStatusReserved, ProductStatusSold:
+		return "", errors.New("reserved or sold products cannot be edited")
+	case ProductStatusPendingReview, ProductStatusChangesRequested:
+		return ProductStatusPendingReview, nil
+	}
+
+	if requested != "" && requested != ProductStatusAvailable {
+		return "", fmt.Errorf("invalid status, only %s can be set by the owner", ProductStatusAvailable)
+	}
+
+	return current, nil
+}
+
+// resubmitForReview - edit material (nama, harga, kategori, foto) oleh pemilik
+// mengembalikan listing yang sudah tayang ke antrean review. Staff yang boleh
+// approve tidak perlu review ulang. Return true jika status berubah.
+func resubmitForReview(tx *gorm.DB, actor AuditActor, product *models.Product) (bool, error) {
+	if product.Status != ProductStatusAvailable || rbac.Can(actor.Role, rbac.PermProductApprove) {
+		return false, nil
+	}
+
+	result := tx.Model(&models.Product{}).
+		Where("id = ? AND status = ?", product.ID, ProductStatusAvailable).
+		Updates(map[string]interface{}{
+			"status":  ProductStatusPendingReview,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, errors.New("product was changed by another request, please reload and try again")
+	}
+
+	product.Status = ProductStatusPendingReview
+	return true, nil
+}
@@ -1,74 +1,247 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sk8consign-backend/database"
 	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"sort"
 	"strings"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// SearchProducts - search products dengan filters
-func SearchProducts(query string, category string, minPrice float64, maxPrice float64, status string, limit int, offset int) ([]models.Product, int64, error) {
-	var products []models.Product
-	var total int64
+// Urutan hasil pencarian
+const (
+	ProductSortRelevance  = "relevance"
+	ProductSortNewest     = "newest"
+	ProductSortPriceAsc   = "price_asc"
+	ProductSortPriceDesc  = "price_desc"
+	ProductSortMostViewed = "most_viewed"
+)
+
+// productSortOrders - ORDER BY per opsi sort; relevance diurutkan dari peringkat index
+var productSortOrders = map[string]string{
+	ProductSortNewest:     "created_at DESC",
+	ProductSortPriceAsc:   "price ASC, created_at DESC",
+	ProductSortPriceDesc:  "price DESC, created_at DESC",
+	ProductSortMostViewed: "view_count DESC, created_at DESC",
+}
+
+// ErrInvalidProductSort - opsi sort tidak dikenal
+var ErrInvalidProductSort = fmt.Errorf("invalid sort, must be one of: %s, %s, %s, %s, %s",
+	ProductSortRelevance, ProductSortNewest, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortMostViewed)
+
+// ProductSearchParams - filter, sort, dan pagination pencarian katalog.
+// Filter multi-select (Conditions, SellerIDs, Brands, Sizes, PriceBuckets)
+// dicocokkan dengan OR di dalam satu filter dan AND antar filter.
+type ProductSearchParams struct {
+	Query        string
+	Category     string
+	Conditions   []string
+	SellerIDs    []string
+	Brands       []string
+	Sizes        []string
+	PriceBuckets []string
+	MinPrice     float64
+	MaxPrice     float64
+	Status       string
+	Sort         string // kosong = relevance jika ada query, selain itu newest
+	Limit        int
+	Offset       int
+}
+
+// ProductSearchResult - satu halaman hasil pencarian beserta facet untuk sidebar filter
+type ProductSearchResult struct {
+	Products []models.Product
+	Total    int64
+	Sort     string
+	Facets   ProductFacets
+}
 
-	// Build query
-	db := database.DB.Model(&models.Product{}).Preload("User")
+// SearchProducts - search products dengan filters. Jika ada query, teks dicari
+// lewat SearchIndex (dengan snippet highlight) dan secara default diurutkan
+// berdasarkan relevansi; tanpa query default dari yang terbaru.
+func SearchProducts(ctx context.Context, params ProductSearchParams) (*ProductSearchResult, error) {
+	params.Query = strings.TrimSpace(params.Query)
+
+	sortBy := params.Sort
+	if sortBy == "" || (sortBy == ProductSortRelevance && params.Query == "") {
+		sortBy = ProductSortNewest
+		if params.Query != "" {
+			sortBy = ProductSortRelevance
+		}
+	}
+	if _, ok := productSortOrders[sortBy]; !ok && sortBy != ProductSortRelevance {
+		return nil, ErrInvalidProductSort
+	}
+	for _, key := range params.PriceBuckets {
+		if findPriceBucket(key) == nil {
+			return nil, ErrInvalidPriceBucket
+		}
+	}
 
-	// Filter by search query (nama atau deskripsi)
-	if query != "" {
-		searchTerm := "%" + strings.ToLower(query) + "%"
-		db = db.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+	result := &ProductSearchResult{Products: []models.Product{}, Sort: sortBy}
+
+	// Filter by search query (nama atau deskripsi) lewat index full-text
+	var ranks map[string]int
+	var highlights map[string]map[string]string
+	var matchedIDs []string
+	if params.Query != "" {
+		var err error
+		ranks, highlights, err = rankedSearchHits(ctx, params.Query)
+		if err != nil {
+			return nil, err
+		}
+		if len(ranks) == 0 {
+			result.Facets = emptyProductFacets()
+			return result, nil
+		}
+
+		matchedIDs = make([]string, 0, len(ranks))
+		for id := range ranks {
+			matchedIDs = append(matchedIDs, id)
+		}
 	}
 
-	// Filter by category
-	if category != "" && category != "all" {
-		db = db.Where("category = ?", category)
+	facets, err := productFacets(ctx, params, matchedIDs)
+	if err != nil {
+		return nil, err
 	}
+	result.Facets = *facets
 
-	// Filter by price range
-	if minPrice > 0 {
-		db = db.Where("price >= ?", minPrice)
+	db := applyProductFilters(database.DB.WithContext(ctx).Model(&models.Product{}), params, matchedIDs, "")
+
+	// Count total
+	if err := db.Count(&result.Total).Error; err != nil {
+		return nil, err
 	}
-	if maxPrice > 0 {
-		db = db.Where("price <= ?", maxPrice)
+
+	var products []models.Product
+	if sortBy != ProductSortRelevance {
+		db = db.Order(productSortOrders[sortBy])
+		if params.Limit > 0 {
+			db = db.Limit(params.Limit).Offset(params.Offset)
+		}
+
+		if err := db.Preload("User").Preload("Images", galleryOrder).Find(&products).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		// Urut relevansi: ambil id yang lolos filter, urutkan sesuai peringkat index,
+		// lalu muat satu halaman saja
+		var filtered []string
+		if err := db.Pluck("id", &filtered).Error; err != nil {
+			return nil, err
+		}
+		sortByRank(filtered, ranks)
+
+		page := paginateIDs(filtered, params.Limit, params.Offset)
+		if len(page) == 0 {
+			return result, nil
+		}
+
+		if err := database.DB.WithContext(ctx).Preload("User").Preload("Images", galleryOrder).
+			Where("id IN ?", page).Find(&products).Error; err != nil {
+			return nil, err
+		}
+
+		position := make(map[string]int, len(page))
+		for i, id := range page {
+			position[id] = i
+		}
+		sort.Slice(products, func(a, b int) bool { return position[products[a].ID] < position[products[b].ID] })
 	}
 
-	// Filter by status
-	if status != "" && status != "all" {
-		db = db.Where("status = ?", status)
+	for i := range products {
+		products[i].Highlights = highlights[products[i].ID]
 	}
+	result.Products = products
 
-	// Only active products
-	db = db.Where("is_active = ?", true)
+	return result, nil
+}
 
-	// Count total
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+// applyProductFilters - terapkan filter pencarian ke query produk. except berisi
+// facet yang filternya dilewati, supaya hitungan facet tetap menampilkan pilihan
+// lain di grup yang sama (multi-select).
+func applyProductFilters(db *gorm.DB, params ProductSearchParams, matchedIDs []string, except string) *gorm.DB {
+	if params.Query != "" {
+		db = db.Where("id IN ?", matchedIDs)
 	}
 
-	// Get products dengan pagination
-	if limit > 0 {
-		db = db.Limit(limit).Offset(offset)
+	// Filter by category
+	if except != FacetCategory && params.Category != "" && params.Category != "all" {
+		db = db.Where("category = ?", params.Category)
 	}
 
-	// Order by created_at desc
-	db = db.Order("created_at DESC")
+	// Filter multi-select
+	if conditions := nonEmpty(params.Conditions); except != FacetCondition && len(conditions) > 0 {
+		db = db.Where("`condition` IN ?", conditions)
+	}
+	if sellers := nonEmpty(params.SellerIDs); len(sellers) > 0 {
+		db = db.Where("user_id IN ?", sellers)
+	}
+	if brands := nonEmpty(params.Brands); len(brands) > 0 {
+		db = db.Where("brand IN ?", brands)
+	}
+	if sizes := nonEmpty(params.Sizes); len(sizes) > 0 {
+		db = db.Where("size IN ?", sizes)
+	}
 
-	if err := db.Find(&products).Error; err != nil {
-		return nil, 0, err
+	// Filter by price range / bucket
+	if except != FacetPrice {
+		if params.MinPrice > 0 {
+			db = db.Where("price >= ?", params.MinPrice)
+		}
+		if params.MaxPrice > 0 {
+			db = db.Where("price <= ?", params.MaxPrice)
+		}
+		if len(params.PriceBuckets) > 0 {
+			db = db.Where(priceBucketCondition(params.PriceBuckets))
+		}
 	}
 
-	return products, total, nil
+	// Filter by status; listing yang belum lolos review tidak pernah tampil
+	if params.Status != "" && params.Status != "all" {
+		db = db.Where("status = ?", params.Status)
+	}
+	db = db.Where("status IN ?", publicProductStatuses)
+
+	// Only active products
+	return db.Where("is_active = ?", true)
+}
+
+// nonEmpty - buang nilai kosong dari filter multi-select
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// paginateIDs - potong daftar id sesuai limit / offset (limit 0 = semua)
+func paginateIDs(ids []string, limit, offset int) []string {
+	if offset >= len(ids) {
+		return nil
+	}
+	ids = ids[offset:]
+	if limit > 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+	return ids
 }
 
 // GetProductByID - get product by ID
 func GetProductByID(productID string) (*models.Product, error) {
 	var product models.Product
 
-	if err := database.DB.Preload("User").Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+	if err := database.DB.Preload("User").Preload("Images", galleryOrder).Where("id = ? AND is_active = ? AND status IN ?", productID, true, publicProductStatuses).First(&product).Error; err != nil {
 		return nil, errors.New("product not found")
 	}
 
@@ -83,7 +256,7 @@ func GetUserProducts(userID string, status string, limit int, offset int) ([]mod
 	var products []models.Product
 	var total int64
 
-	db := database.DB.Model(&models.Product{}).Where("user_id = ?", userID)
+	db := database.DB.Model(&models.Product{}).Preload("Images", galleryOrder).Where("user_id = ?", userID)
 
 	if status != "" && status != "all" {
 		db = db.Where("status = ?", status)
@@ -108,39 +281,81 @@ func GetUserProducts(userID string, status string, limit int, offset int) ([]mod
 	return products, total, nil
 }
 
-// CreateProduct - create new product
-func CreateProduct(userID string, name string, description string, price float64, category string, condition string, imageURL string) (*models.Product, error) {
+// CreateProduct - create new product. Listing consignor masuk antrean review
+// ("pending_review"); staff yang berhak approve langsung menayangkannya.
+func CreateProduct(actor AuditActor, name string, description string, price float64, category string, condition string, brand string, size string, imageURL string) (*models.Product, error) {
 	product := models.Product{
 		ID:          uuid.New().String(),
-		UserID:      userID,
+		UserID:      actor.UserID,
 		Name:        name,
 		Description: description,
 		Price:       price,
 		Category:    category,
 		Condition:   condition,
-		Status:      "available",
+		Brand:       strings.TrimSpace(brand),
+		Size:        strings.TrimSpace(size),
+		Status:      ProductStatusPendingReview,
 		ImageURL:    imageURL,
 		IsActive:    true,
 	}
 
-	if err := database.DB.Create(&product).Error; err != nil {
+	if rbac.Can(actor.Role, rbac.PermProductApprove) {
+		product.Status = ProductStatusAvailable
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		return RecordAuditChange(tx, actor, AuditProductCreate, "product", product.ID, nil, productAuditFields(&product))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Load user relation
-	database.DB.Preload("User").First(&product, "id = ?", product.ID)
+	database.DB.Preload("User").Preload("Images", galleryOrder).First(&product, "id = ?", product.ID)
+	indexProduct(&product)
+
+	if product.Status == ProductStatusPendingReview {
+		publishProductEvent(&product, EventProductSubmitted, "")
+	}
 
 	return &product, nil
 }
 
 // UpdateProduct - update product
-func UpdateProduct(productID string, userID string, name string, description string, price float64, category string, condition string, status string, imageURL string) (*models.Product, error) {
+func UpdateProduct(actor AuditActor, productID string, name string, description string, price float64, category string, condition string, brand string, size string, status string, imageURL string) (*models.Product, error) {
 	var product models.Product
 
 	// Check if product exists dan milik user
-	if err := database.DB.Where("id = ? AND user_id = ?", productID, userID).First(&product).Error; err != nil {
+	if err := database.DB.Where("id = ? AND user_id = ?", productID, actor.UserID).First(&product).Error; err != nil {
 		return nil, errors.New("product not found or unauthorized")
 	}
+	before := productAuditFields(&product)
+
+	nextStatus, err := nextOwnerStatus(product.Status, status)
+	if err != nil {
+		return nil, err
+	}
+	resubmitted := product.Status == ProductStatusChangesRequested
+
+	// Edit material pada listing yang sudah tayang harus direview ulang
+	material := name != product.Name || price != product.Price || category != product.Category ||
+		(imageURL != "" && imageURL != product.ImageURL)
+	if material && nextStatus == ProductStatusAvailable && !rbac.Can(actor.Role, rbac.PermProductApprove) {
+		nextStatus = ProductStatusPendingReview
+		resubmitted = true
+	}
+
+	// Harga tidak boleh di bawah floor price perjanjian titip jual
+	agreement, err := GetActiveAgreement(database.DB, productID)
+	if err != nil {
+		return nil, err
+	}
+	if agreement != nil && price < agreement.FloorPrice {
+		return nil, errors.New("price cannot be lower than the agreed floor price")
+	}
 
 	// Update fields
 	updates := map[string]interface{}{
@@ -149,40 +364,84 @@ func UpdateProduct(productID string, userID string, name string, description str
 		"price":       price,
 		"category":    category,
 		"condition":   condition,
-		"status":      status,
+		"brand":       strings.TrimSpace(brand),
+		"size":        strings.TrimSpace(size),
+		"status":      nextStatus,
+		"version":     gorm.Expr("version + 1"),
 	}
 
 	if imageURL != "" {
 		updates["image_url"] = imageURL
 	}
 
-	if err := database.DB.Model(&product).Updates(updates).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Update bersyarat supaya edit tidak menimpa keputusan reviewer yang baru masuk
+		result := tx.Model(&product).Where("status = ?", product.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("product was changed by another request, please reload and try again")
+		}
+		if err := tx.First(&product, "id = ?", product.ID).Error; err != nil {
+			return err
+		}
+		return RecordAuditChange(tx, actor, AuditProductUpdate, "product", product.ID, before, productAuditFields(&product))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Reload product with user
-	database.DB.Preload("User").First(&product, "id = ?", product.ID)
+	database.DB.Preload("User").Preload("Images", galleryOrder).First(&product, "id = ?", product.ID)
+	indexProduct(&product)
+
+	if resubmitted {
+		publishProductEvent(&product, EventProductSubmitted, "")
+	}
 
 	return &product, nil
 }
 
 // DeleteProduct - soft delete product
-func DeleteProduct(productID string, userID string) error {
+func DeleteProduct(actor AuditActor, productID string) error {
 	var product models.Product
 
 	// Check if product exists dan milik user
-	if err := database.DB.Where("id = ? AND user_id = ?", productID, userID).First(&product).Error; err != nil {
+	if err := database.DB.Where("id = ? AND user_id = ?", productID, actor.UserID).First(&product).Error; err != nil {
 		return errors.New("product not found or unauthorized")
 	}
 
 	// Soft delete
-	if err := database.DB.Delete(&product).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return RecordAuditChange(tx, actor, AuditProductDelete, "product", product.ID, productAuditFields(&product), nil)
+	})
+	if err != nil {
 		return err
 	}
 
+	unindexProduct(product.ID)
 	return nil
 }
 
+// productAuditFields - field produk yang dicatat di audit log
+func productAuditFields(product *models.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        product.Name,
+		"description": product.Description,
+		"price":       product.Price,
+		"category":    product.Category,
+		"condition":   product.Condition,
+		"brand":       product.Brand,
+		"size":        product.Size,
+		"status":      product.Status,
+		"image_url":   product.ImageURL,
+	}
+}
+
 // GetCategories - get available categories
 func GetCategories() []string {
 	return []string{
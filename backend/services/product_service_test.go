@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+)
+
+// Pemilik hanya boleh mengedit listing available: status reserved / sold diatur
+// checkout, dan perubahan nama / harga / kategori / foto harus direview ulang.
+func TestUpdateProductOwnerRules(t *testing.T) {
+	setupTestDB(t)
+
+	seller := createTestUser(t, rbac.RoleConsignor)
+	actor := AuditActor{UserID: seller.ID, Role: seller.Role}
+	product := createTestProduct(t, seller, 500000)
+
+	update := func(name string, price float64, status string) (*models.Product, error) {
+		return UpdateProduct(actor, product.ID, name, "updated description", price, product.Category,
+			product.Condition, "", "", status, "")
+	}
+	assertStatus := func(want string) {
+		t.Helper()
+		var stored models.Product
+		if err := database.DB.First(&stored, "id = ?", product.ID).Error; err != nil {
+			t.Fatalf("reload product: %v", err)
+		}
+		if stored.Status != want {
+			t.Errorf("product status = %q, want %q", stored.Status, want)
+		}
+	}
+
+	if _, err := update(product.Name, product.Price, ProductStatusSold); err == nil {
+		t.Error("owner set status sold, want error")
+	}
+	assertStatus(ProductStatusAvailable)
+
+	// Deskripsi bukan perubahan material: tetap tayang
+	if _, err := update(product.Name, product.Price, ""); err != nil {
+		t.Fatalf("edit description: %v", err)
+	}
+	assertStatus(ProductStatusAvailable)
+
+	updated, err := update(product.Name, 450000, "")
+	if err != nil {
+		t.Fatalf("edit price: %v", err)
+	}
+	if updated.Status != ProductStatusPendingReview {
+		t.Errorf("status after price change = %q, want %q", updated.Status, ProductStatusPendingReview)
+	}
+	assertStatus(ProductStatusPendingReview)
+
+	database.DB.Model(&models.Product{}).Where("id = ?", product.ID).Update("status", ProductStatusReserved)
+	if _, err := update("Renamed Deck", 450000, ""); err == nil {
+		t.Error("edit of a reserved product succeeded, want error")
+	}
+	assertStatus(ProductStatusReserved)
+}
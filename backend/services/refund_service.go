@@ -0,0 +1,659 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sk8consign-backend/database"
+	"sk8consign-backend/ledger"
+	"sk8consign-backend/models"
+	"sk8consign-backend/payment"
+	"sk8consign-backend/rbac"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status return request
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+)
+
+// Status refund. Refund lewat gateway "pending" sampai provider mengonfirmasi.
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusCompleted = "completed"
+)
+
+// ErrRefundBeforeShipment - order yang belum dikirim di-refund lewat pembatalan,
+// supaya produk dilepas dan seller tidak bisa lagi mengirimnya
+var ErrRefundBeforeShipment = errors.New("order has not been shipped yet, cancel the order to refund the buyer")
+
+// maxReturnPhotos - batas foto bukti per return request
+const maxReturnPhotos = 5
+
+// RefundItemInput - item yang di-refund. Amount 0 berarti seluruh sisa subtotal item.
+type RefundItemInput struct {
+	OrderItemID string
+	Amount      float64
+}
+
+// CreateReturnRequest - buyer mengajukan retur untuk item order yang sudah diterima
+func CreateReturnRequest(orderID, buyerID, reason string, photoURLs []string, items []RefundItemInput) (*models.ReturnRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("return reason is required")
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+
+	if len(photoURLs) > maxReturnPhotos {
+		return nil, fmt.Errorf("a maximum of %d photos is allowed", maxReturnPhotos)
+	}
+
+	returnRequest := models.ReturnRequest{
+		ID:        uuid.New().String(),
+		OrderID:   orderID,
+		BuyerID:   buyerID,
+		Reason:    reason,
+		PhotoURLs: photoURLs,
+		Status:    ReturnStatusRequested,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Where("id = ? AND user_id = ?", orderID, buyerID).First(&order).Error; err != nil {
+			return errors.New("order not found")
+		}
+
+		if order.Status != OrderStatusDelivered && order.Status != OrderStatusCompleted {
+			return errors.New("only delivered or completed orders can be returned")
+		}
+
+		seen := make(map[string]bool)
+		for _, input := range items {
+			if seen[input.OrderItemID] {
+				return errors.New("duplicate order item in return request")
+			}
+			seen[input.OrderItemID] = true
+
+			var item models.OrderItem
+			if err := tx.Where("id = ? AND order_id = ?", input.OrderItemID, orderID).First(&item).Error; err != nil {
+				return errors.New("order item not found")
+			}
+
+			amount, err := refundableAmount(item, input.Amount)
+			if err != nil {
+				return err
+			}
+
+			var open int64
+			if err := tx.Model(&models.ReturnItem{}).
+				Joins("JOIN return_requests ON return_requests.id = return_items.return_id").
+				Where("return_items.order_item_id = ? AND return_requests.status = ? AND return_requests.deleted_at IS NULL", item.ID, ReturnStatusRequested).
+				Count(&open).Error; err != nil {
+				return err
+			}
+			if open > 0 {
+				return errors.New("order item already has an open return request")
+			}
+
+			returnRequest.Items = append(returnRequest.Items, models.ReturnItem{
+				ID:           uuid.New().String(),
+				ReturnID:     returnRequest.ID,
+				OrderItemID:  item.ID,
+				RefundAmount: amount,
+			})
+		}
+
+		return tx.Create(&returnRequest).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	publishOrderEvent(orderID, EventReturnRequested, reason)
+
+	return GetReturnRequestByID(returnRequest.ID)
+}
+
+// GetReturnRequestByID - detail return request beserta item dan produknya
+func GetReturnRequestByID(returnID string) (*models.ReturnRequest, error) {
+	var returnRequest models.ReturnRequest
+
+	if err := database.DB.Preload("Items.OrderItem.Product").First(&returnRequest, "id = ?", returnID).Error; err != nil {
+		return nil, errors.New("return request not found")
+	}
+
+	return &returnRequest, nil
+}
+
+// GetBuyerReturnRequests - return request milik buyer
+func GetBuyerReturnRequests(buyerID, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+	query := database.DB.Model(&models.ReturnRequest{}).Where("buyer_id = ?", buyerID)
+	return listReturnRequests(query, status, limit, offset)
+}
+
+// GetSellerReturnRequests - return request yang berisi produk milik seller
+func GetSellerReturnRequests(sellerID, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+	sellerItemIDs := database.DB.Model(&models.OrderItem{}).Select("id").Where("product_id IN (?)", sellerProductIDs(database.DB, sellerID))
+	returnIDs := database.DB.Model(&models.ReturnItem{}).Select("return_id").Where("order_item_id IN (?)", sellerItemIDs)
+
+	query := database.DB.Model(&models.ReturnRequest{}).Where("id IN (?)", returnIDs)
+	return listReturnRequests(query, status, limit, offset)
+}
+
+// GetAllReturnRequests - semua return request (admin)
+func GetAllReturnRequests(status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+	return listReturnRequests(database.DB.Model(&models.ReturnRequest{}), status, limit, offset)
+}
+
+func listReturnRequests(query *gorm.DB, status string, limit, offset int) ([]models.ReturnRequest, int64, error) {
+	var returnRequests []models.ReturnRequest
+	var total int64
+
+	if status != "" && status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+
+	err := query.Preload("Items.OrderItem.Product").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&returnRequests).Error
+
+	return returnRequests, total, err
+}
+
+// ReviewReturnRequest - seller pemilik item atau admin menyetujui / menolak retur.
+// Jika disetujui item di-refund, earning consignor dibalik, dan produk di-relist.
+func ReviewReturnRequest(ctx context.Context, returnID, reviewerID, role string, approve bool, note string) (*models.ReturnRequest, error) {
+	note = strings.TrimSpace(note)
+	if !approve && note == "" {
+		return nil, errors.New("rejection reason is required")
+	}
+
+	var returnRequest models.ReturnRequest
+	var refundTotal float64
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Items.OrderItem.Product").First(&returnRequest, "id = ?", returnID).Error; err != nil {
+			return errors.New("return request not found")
+		}
+
+		actor := Actor{UserID: reviewerID, Role: ActorAdmin}
+		if !rbac.Can(role, rbac.PermReturnManage) {
+			actor.Role = ActorSeller
+			for _, item := range returnRequest.Items {
+				if item.OrderItem.Product.UserID != reviewerID {
+					return errors.New("return request not found")
+				}
+			}
+		}
+
+		if returnRequest.Status != ReturnStatusRequested {
+			return errors.New("return request has already been reviewed")
+		}
+
+		status := ReturnStatusRejected
+		if approve {
+			status = ReturnStatusApproved
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.ReturnRequest{}).
+			Where("id = ? AND status = ?", returnRequest.ID, ReturnStatusRequested).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"reviewer_id": reviewerID,
+				"review_note": note,
+				"reviewed_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("return request has already been reviewed")
+		}
+
+		if !approve {
+			return nil
+		}
+
+		order, err := lockRefundableOrder(tx, returnRequest.OrderID)
+		if err != nil {
+			return err
+		}
+
+		returnRef := returnRequest.ID
+		for _, item := range returnRequest.Items {
+			refund, err := issueItemRefund(tx, order, item.OrderItem, item.RefundAmount, "return approved", &returnRef, reviewerID)
+			if err != nil {
+				return err
+			}
+			refundTotal += refund.Amount
+
+			if err := relistProduct(tx, item.OrderItem.ProductID); err != nil {
+				return err
+			}
+		}
+
+		return settleRefundedOrder(tx, order, actor, note)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		sendPendingRefunds(ctx, returnRequest.OrderID)
+		publishRefundEvent(returnRequest.OrderID, EventReturnApproved, roundMoney(refundTotal), note)
+	} else {
+		publishOrderEvent(returnRequest.OrderID, EventReturnRejected, note)
+	}
+
+	return GetReturnRequestByID(returnRequest.ID)
+}
+
+// RefundOrder - admin me-refund item order (partial / full) tanpa proses retur.
+// Tanpa items, seluruh sisa nilai order di-refund.
+func RefundOrder(ctx context.Context, orderID, adminID string, items []RefundItemInput, reason string) ([]models.Refund, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("refund reason is required")
+	}
+
+	var refunds []models.Refund
+	var orderStatus string
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockRefundableOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		var orderItems []models.OrderItem
+		if err := tx.Where("order_id = ?", orderID).Find(&orderItems).Error; err != nil {
+			return err
+		}
+
+		itemsByID := make(map[string]models.OrderItem, len(orderItems))
+		for _, item := range orderItems {
+			itemsByID[item.ID] = item
+		}
+
+		if len(items) == 0 {
+			for _, item := range orderItems {
+				if roundMoney(item.Subtotal-item.RefundedAmount) > 0 {
+					items = append(items, RefundItemInput{OrderItemID: item.ID})
+				}
+			}
+		}
+
+		for _, input := range items {
+			item, ok := itemsByID[input.OrderItemID]
+			if !ok {
+				return errors.New("order item not found")
+			}
+
+			refund, err := issueItemRefund(tx, order, item, input.Amount, reason, nil, adminID)
+			if err != nil {
+				return err
+			}
+			refunds = append(refunds, *refund)
+		}
+
+		if err := settleRefundedOrder(tx, order, Actor{UserID: adminID, Role: ActorAdmin}, reason); err != nil {
+			return err
+		}
+
+		orderStatus = order.Status
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sendPendingRefunds(ctx, orderID)
+
+	var total float64
+	refundIDs := make([]string, len(refunds))
+	for i, refund := range refunds {
+		total += refund.Amount
+		refundIDs[i] = refund.ID
+	}
+
+	// Baca ulang supaya status / provider_ref hasil pengiriman ke provider ikut dikembalikan
+	if err := database.DB.Where("id IN ?", refundIDs).Order("created_at ASC").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+
+	if orderStatus == OrderStatusRefunded {
+		publishRefundEvent(orderID, EventOrderRefunded, roundMoney(total), reason)
+	} else {
+		publishRefundEvent(orderID, EventRefundIssued, roundMoney(total), reason)
+	}
+
+	return refunds, nil
+}
+
+// lockRefundableOrder - kunci baris order supaya refund paralel tidak melebihi nilai item.
+// Hanya order yang sudah dikirim: state machine tidak punya transisi confirmed -> refunded.
+func lockRefundableOrder(tx *gorm.DB, orderID string) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	switch order.Status {
+	case OrderStatusShipped, OrderStatusDelivered, OrderStatusCompleted:
+	default:
+		return nil, ErrRefundBeforeShipment
+	}
+
+	if order.PaymentStatus != "paid" && order.PaymentStatus != "partially_refunded" {
+		return nil, errors.New("order has no payment to refund")
+	}
+
+	return &order, nil
+}
+
+// refundableAmount - validasi nominal refund terhadap sisa subtotal item
+func refundableAmount(item models.OrderItem, requested float64) (float64, error) {
+	remaining := roundMoney(item.Subtotal - item.RefundedAmount)
+	if remaining <= 0 {
+		return 0, errors.New("order item has already been fully refunded")
+	}
+
+	if requested == 0 {
+		return remaining, nil
+	}
+
+	requested = roundMoney(requested)
+	if requested < 0 || requested > remaining {
+		return 0, fmt.Errorf("refund amount must be between 0 and %s", formatRupiah(remaining))
+	}
+
+	return requested, nil
+}
+
+// issueItemRefund - refund satu OrderItem: payout consignor dibalik proporsional
+// dan jurnal refund diposting. Refund lewat gateway dicatat "pending"; pemanggil
+// mengirimnya ke provider dengan sendPendingRefunds setelah transaksi commit.
+// Harus dipanggil di dalam transaksi setelah order dikunci.
+func issueItemRefund(tx *gorm.DB, order *models.Order, item models.OrderItem, requested float64, reason string, returnID *string, processedBy string) (*models.Refund, error) {
+	// Baca ulang item supaya refunded_amount yang dipakai adalah yang terbaru
+	if err := tx.First(&item, "id = ?", item.ID).Error; err != nil {
+		return nil, errors.New("order item not found")
+	}
+
+	amount, err := refundableAmount(item, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	// Order terkunci, jadi urutan refund per item stabil; transaksi yang rollback
+	// memakai ulang urutan (dan idempotency key) yang sama saat dicoba lagi
+	var previous int64
+	if err := tx.Model(&models.Refund{}).Where("order_item_id = ?", item.ID).Count(&previous).Error; err != nil {
+		return nil, err
+	}
+
+	refund := models.Refund{
+		ID:              uuid.New().String(),
+		OrderID:         order.ID,
+		OrderItemID:     item.ID,
+		Sequence:        int(previous) + 1,
+		ReturnID:        returnID,
+		Amount:          amount,
+		CommissionShare: amount,
+		Reason:          reason,
+	}
+	refund.IdempotencyKey = fmt.Sprintf("%s-%d", item.ID, refund.Sequence)
+	if processedBy != "" {
+		refund.ProcessedBy = &processedBy
+	}
+
+	if err := prepareProviderRefund(tx, order.ID, &refund); err != nil {
+		return nil, err
+	}
+
+	consignorID := ""
+	var payout models.ConsignorPayout
+	err = tx.Where("order_item_id = ?", item.ID).First(&payout).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err == nil {
+		consignorID = payout.ConsignorID
+
+		// Bagian consignor mengikuti porsi payout terhadap harga jual
+		if payout.GrossAmount > 0 {
+			share := roundMoney(payout.PayoutAmount * amount / payout.GrossAmount)
+			refund.ConsignorShare = math.Min(share, roundMoney(payout.PayoutAmount-payout.RefundedAmount))
+		}
+		refund.CommissionShare = roundMoney(amount - refund.ConsignorShare)
+
+		// Payout yang sudah dibayar tetap "paid"; selisihnya menjadi utang consignor di ledger
+		updates := map[string]interface{}{
+			"refunded_amount": roundMoney(payout.RefundedAmount + refund.ConsignorShare),
+		}
+		if payout.Status == "pending" && roundMoney(payout.RefundedAmount+refund.ConsignorShare) >= payout.PayoutAmount {
+			updates["status"] = "reversed"
+		}
+
+		if err := tx.Model(&payout).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.OrderItem{}).
+		Where("id = ?", item.ID).
+		Update("refunded_amount", roundMoney(item.RefundedAmount+amount)).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := ledger.PostRefund(tx, "refund", refund.ID, order.UserID, consignorID, refund.ConsignorShare, refund.CommissionShare); err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// prepareProviderRefund - tentukan jalur refund. Order yang dibayar di luar gateway
+// (tanpa intent paid) langsung "completed"; selain itu refund "pending" dengan
+// reference pembayaran yang akan di-refund di provider.
+func prepareProviderRefund(tx *gorm.DB, orderID string, refund *models.Refund) error {
+	var intent models.PaymentIntent
+	err := tx.Where("order_id = ? AND status = ?", orderID, payment.StatusPaid).
+		Order("updated_at DESC").
+		First(&intent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		now := time.Now()
+		refund.Status = RefundStatusCompleted
+		refund.CompletedAt = &now
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if paymentProvider == nil || paymentProvider.Name() != intent.Provider {
+		return fmt.Errorf("payment provider %s is not available for refunds", intent.Provider)
+	}
+
+	refund.Status = RefundStatusPending
+	refund.Provider = intent.Provider
+	refund.PaymentReference = intent.Reference
+	return nil
+}
+
+// sendPendingRefunds - kirim refund "pending" ke provider setelah transaksi commit
+// (orderID kosong = semua order). Refund yang gagal tetap pending dan dicoba ulang
+// oleh RunRefundWorker dengan idempotency key yang sama. Return jumlah yang terkirim.
+func sendPendingRefunds(ctx context.Context, orderID string) int {
+	var refunds []models.Refund
+	query := database.DB.WithContext(ctx).Where("status = ?", RefundStatusPending)
+	if orderID != "" {
+		query = query.Where("order_id = ?", orderID)
+	}
+	if err := query.Order("created_at ASC").Find(&refunds).Error; err != nil {
+		log.Printf("❌ Failed to load pending refunds: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range refunds {
+		if err := sendRefund(ctx, &refunds[i]); err != nil {
+			log.Printf("❌ Refund %s for order %s failed: %v", refunds[i].ID, refunds[i].OrderID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent
+}
+
+// sendRefund - kembalikan dana lewat provider yang menerima pembayaran
+func sendRefund(ctx context.Context, refund *models.Refund) error {
+	if paymentProvider == nil || paymentProvider.Name() != refund.Provider {
+		return fmt.Errorf("payment provider %s is not available for refunds", refund.Provider)
+	}
+
+	reference, err := paymentProvider.Refund(ctx, payment.RefundRequest{
+		Reference:      refund.PaymentReference,
+		IdempotencyKey: refund.IdempotencyKey,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return database.DB.Model(&models.Refund{}).
+		Where("id = ? AND status = ?", refund.ID, RefundStatusPending).
+		Updates(map[string]interface{}{
+			"status":       RefundStatusCompleted,
+			"provider_ref": reference,
+			"completed_at": &now,
+		}).Error
+}
+
+// RunRefundWorker - loop background yang mengirim ulang refund gateway yang
+// masih pending. Berhenti saat ctx dibatalkan.
+func RunRefundWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("💸 Refund worker started (interval %s)", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("💸 Refund worker stopped")
+			return
+		case <-ticker.C:
+			if sent := sendPendingRefunds(ctx, ""); sent > 0 {
+				log.Printf("💸 Sent %d pending refund(s) to the payment provider", sent)
+			}
+			if refunded := refundLatePayments(ctx, ""); refunded > 0 {
+				log.Printf("💸 Refunded %d late payment(s)", refunded)
+			}
+		}
+	}
+}
+
+// relistProduct - produk yang diretur kembali dijual
+func relistProduct(tx *gorm.DB, productID string) error {
+	return tx.Model(&models.Product{}).
+		Where("id = ? AND status = ?", productID, "sold").
+		Updates(map[string]interface{}{
+			"status":  "available",
+			"version": gorm.Expr("version + 1"),
+		}).Error
+}
+
+// settleRefundedOrder - sinkronkan payment_status, lalu pindahkan order ke
+// "refunded" setelah seluruh nilainya dikembalikan
+func settleRefundedOrder(tx *gorm.DB, order *models.Order, actor Actor, note string) error {
+	if err := syncRefundPaymentStatus(tx, order, actor); err != nil {
+		return err
+	}
+
+	if order.PaymentStatus != "refunded" {
+		return nil
+	}
+
+	if _, ok := orderTransitions[order.Status][OrderStatusRefunded]; !ok {
+		return nil
+	}
+
+	return TransitionOrder(tx, order, OrderStatusRefunded, actor, note)
+}
+
+// syncRefundPaymentStatus - payment_status mengikuti total refund item
+func syncRefundPaymentStatus(tx *gorm.DB, order *models.Order, actor Actor) error {
+	var refunded float64
+	if err := tx.Model(&models.OrderItem{}).
+		Where("order_id = ?", order.ID).
+		Select("COALESCE(SUM(refunded_amount), 0)").
+		Scan(&refunded).Error; err != nil {
+		return err
+	}
+
+	paymentStatus := "partially_refunded"
+	if roundMoney(refunded) >= roundMoney(order.TotalAmount) {
+		paymentStatus = "refunded"
+	}
+
+	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("payment_status", paymentStatus).Error; err != nil {
+		return err
+	}
+	if err := recordPaymentStatusChange(tx, order, order.PaymentStatus, paymentStatus, actor); err != nil {
+		return err
+	}
+	order.PaymentStatus = paymentStatus
+
+	return nil
+}
+
+// refundCancelledOrder - side effect cancel order yang sudah dibayar: seluruh dana dikembalikan.
+// Refund gateway dikirim oleh pemanggil TransitionOrder setelah commit (sendPendingRefunds).
+func refundCancelledOrder(tx *gorm.DB, order *models.Order) error {
+	var orderItems []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&orderItems).Error; err != nil {
+		return err
+	}
+
+	for _, item := range orderItems {
+		if roundMoney(item.Subtotal-item.RefundedAmount) <= 0 {
+			continue
+		}
+		if _, err := issueItemRefund(tx, order, item, 0, "order cancelled", nil, ""); err != nil {
+			return err
+		}
+	}
+
+	// Order sudah "cancelled", cukup sinkronkan payment_status. Siapa yang membatalkan
+	// tercatat di audit perubahan status; refund-nya sendiri dicatat sebagai system.
+	return syncRefundPaymentStatus(tx, order, SystemActor)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+)
+
+// Order confirmed (sudah dibayar, belum dikirim) tidak bisa di-refund langsung:
+// tidak ada transisi confirmed -> refunded, jadi produk tetap "sold" dan seller
+// masih bisa mengirimnya. Jalurnya lewat pembatalan.
+func TestRefundOrderRejectsConfirmedOrder(t *testing.T) {
+	setupTestDB(t)
+	fake := useFakePaymentProvider(t)
+
+	admin := createTestUser(t, rbac.RoleAdmin)
+	seller := createTestUser(t, rbac.RoleConsignor)
+	buyer := createTestUser(t, rbac.RoleBuyer)
+	product := createTestProduct(t, seller, 2000000)
+	order := createTestOrder(t, buyer, product)
+	payTestOrder(t, fake, order)
+
+	assertOrder := func(status, paymentStatus string) {
+		t.Helper()
+		var stored models.Order
+		if err := database.DB.First(&stored, "id = ?", order.ID).Error; err != nil {
+			t.Fatalf("reload order: %v", err)
+		}
+		if stored.Status != status || stored.PaymentStatus != paymentStatus {
+			t.Errorf("order = %s / %s, want %s / %s", stored.Status, stored.PaymentStatus, status, paymentStatus)
+		}
+	}
+	assertProduct := func(status string) {
+		t.Helper()
+		var stored models.Product
+		if err := database.DB.First(&stored, "id = ?", product.ID).Error; err != nil {
+			t.Fatalf("reload product: %v", err)
+		}
+		if stored.Status != status {
+			t.Errorf("product status = %q, want %q", stored.Status, status)
+		}
+	}
+
+	assertOrder(OrderStatusConfirmed, "paid")
+
+	_, err := RefundOrder(context.Background(), order.ID, admin.ID, nil, "buyer changed their mind")
+	if !errors.Is(err, ErrRefundBeforeShipment) {
+		t.Fatalf("RefundOrder on a confirmed order = %v, want ErrRefundBeforeShipment", err)
+	}
+
+	var refunds int64
+	database.DB.Model(&models.Refund{}).Where("order_id = ?", order.ID).Count(&refunds)
+	if refunds != 0 {
+		t.Errorf("refunds = %d, want none", refunds)
+	}
+	assertOrder(OrderStatusConfirmed, "paid")
+	assertProduct(ProductStatusSold)
+
+	// Pembatalan me-refund penuh dan melepas produk
+	actor := AuditActor{UserID: admin.ID, Role: rbac.RoleAdmin}
+	if err := UpdateOrderStatus(context.Background(), actor, order.ID, OrderStatusCancelled, "buyer changed their mind"); err != nil {
+		t.Fatalf("cancel confirmed order: %v", err)
+	}
+
+	var completed int64
+	database.DB.Model(&models.Refund{}).Where("order_id = ? AND status = ?", order.ID, RefundStatusCompleted).Count(&completed)
+	if completed != 1 {
+		t.Errorf("completed refunds = %d, want 1", completed)
+	}
+	assertOrder(OrderStatusCancelled, "refunded")
+	assertProduct(ProductStatusAvailable)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/payment"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RunReservationWorker - loop background yang membatalkan order pending
+// dengan reservasi kedaluwarsa. Berhenti saat ctx dibatalkan.
+func RunReservationWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("⏱️  Reservation worker started (interval %s)", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("⏱️  Reservation worker stopped")
+			return
+		case <-ticker.C:
+			if expired, err := ExpireReservations(time.Now()); err != nil {
+				log.Printf("❌ Reservation sweep failed: %v", err)
+			} else if expired > 0 {
+				log.Printf("⏱️  Expired %d unpaid order(s)", expired)
+			}
+		}
+	}
+}
+
+// ExpireReservations - batalkan order pending yang reservasinya lewat batas,
+// kembalikan produk ke "available" dan beri tahu buyer. Return jumlah order yang dibatalkan.
+func ExpireReservations(now time.Time) (int, error) {
+	var orderIDs []string
+	err := database.DB.Model(&models.Reservation{}).
+		Distinct("order_id").
+		Where("status = ? AND expires_at <= ?", "active", now).
+		Pluck("order_id", &orderIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		cancelled, err := expireOrder(orderID, now)
+		if err != nil {
+			log.Printf("❌ Failed to expire order %s: %v", orderID, err)
+			continue
+		}
+		if cancelled {
+			expired++
+			publishOrderEvent(orderID, EventOrderExpired, "payment window expired")
+		}
+	}
+
+	return expired, nil
+}
+
+// expireOrder - batalkan satu order jika masih menunggu pembayaran
+func expireOrder(orderID string, now time.Time) (bool, error) {
+	cancelled := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Where("id = ?", orderID).First(&order).Error; err != nil {
+			return err
+		}
+
+		// Order sudah dibayar / berubah status: reservasi tidak lagi relevan
+		if order.Status != OrderStatusPending || order.PaymentStatus == "paid" {
+			return tx.Model(&models.Reservation{}).
+				Where("order_id = ? AND status = ?", orderID, "active").
+				Update("status", "converted").Error
+		}
+
+		if err := TransitionOrder(tx, &order, OrderStatusCancelled, SystemActor, "payment window expired"); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PaymentIntent{}).
+			Where("order_id = ? AND status = ?", orderID, payment.StatusPending).
+			Update("status", payment.StatusExpired).Error; err != nil {
+			return err
+		}
+
+		cancelled = true
+		return nil
+	})
+
+	return cancelled, err
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/search"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxSearchHits - kandidat teratas dari index yang masih difilter database
+const maxSearchHits = 1000
+
+var searchIndex search.SearchIndex
+
+// SetSearchIndex - pasang backend pencarian full-text (MySQL FULLTEXT / Bleve)
+func SetSearchIndex(index search.SearchIndex) {
+	searchIndex = index
+}
+
+// GetSearchIndex - backend pencarian yang aktif
+func GetSearchIndex() search.SearchIndex {
+	return searchIndex
+}
+
+// ReindexProducts - bangun ulang index dari semua produk (untuk index embedded
+// yang tidak ikut tersimpan di database). Mengembalikan jumlah produk.
+func ReindexProducts(ctx context.Context) (int, error) {
+	if searchIndex == nil {
+		return 0, errors.New("search index is not configured")
+	}
+
+	var products []models.Product
+	indexed := 0
+
+	err := database.DB.Model(&models.Product{}).
+		Select("id", "name", "description").
+		FindInBatches(&products, 500, func(tx *gorm.DB, batch int) error {
+			for i := range products {
+				if err := searchIndex.Index(ctx, productDocument(&products[i])); err != nil {
+					return err
+				}
+				indexed++
+			}
+			return nil
+		}).Error
+
+	return indexed, err
+}
+
+// indexProduct - perbarui index setelah produk dibuat / diubah. Gagal index
+// tidak menggagalkan request; hasil pencarian tetap difilter database.
+func indexProduct(product *models.Product) {
+	if searchIndex == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := searchIndex.Index(ctx, productDocument(product)); err != nil {
+		log.Printf("⚠️  Failed to index product %s: %v", product.ID, err)
+	}
+}
+
+// unindexProduct - hapus produk dari index
+func unindexProduct(productID string) {
+	if searchIndex == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := searchIndex.Delete(ctx, productID); err != nil {
+		log.Printf("⚠️  Failed to remove product %s from index: %v", productID, err)
+	}
+}
+
+func productDocument(product *models.Product) search.Document {
+	return search.Document{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+	}
+}
+
+// rankedSearchHits - cari teks di index; hasil berupa urutan id dan highlight per id
+func rankedSearchHits(ctx context.Context, query string) (map[string]int, map[string]map[string]string, error) {
+	if searchIndex == nil {
+		return nil, nil, errors.New("search index is not configured")
+	}
+
+	hits, err := searchIndex.Search(ctx, query, maxSearchHits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ranks := make(map[string]int, len(hits))
+	highlights := make(map[string]map[string]string, len(hits))
+	for rank, hit := range hits {
+		ranks[hit.ID] = rank
+		highlights[hit.ID] = hit.Highlights
+	}
+
+	return ranks, highlights, nil
+}
+
+// sortByRank - urutkan id sesuai peringkat relevansi dari index
+func sortByRank(ids []string, ranks map[string]int) {
+	sort.SliceStable(ids, func(a, b int) bool { return ranks[ids[a]] < ranks[ids[b]] })
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sellerProductIDs - subquery ID produk milik seller
+func sellerProductIDs(db *gorm.DB, sellerID string) *gorm.DB {
+	return db.Model(&models.Product{}).Unscoped().Select("id").Where("user_id = ?", sellerID)
+}
+
+// sellerOrderIDs - subquery ID order yang berisi produk milik seller
+func sellerOrderIDs(db *gorm.DB, sellerID string) *gorm.DB {
+	return db.Model(&models.OrderItem{}).Select("order_id").Where("product_id IN (?)", sellerProductIDs(db, sellerID))
+}
+
+// preloadSellerItems - preload hanya line item milik seller
+func preloadSellerItems(db *gorm.DB, sellerID string) *gorm.DB {
+	return db.
+		Preload("OrderItems", "product_id IN (?)", sellerProductIDs(database.DB, sellerID)).
+		Preload("OrderItems.Product.User")
+}
+
+// GetSellerOrders - list order yang berisi produk milik seller
+func GetSellerOrders(sellerID, status string, limit, offset int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var total int64
+
+	query := database.DB.Model(&models.Order{}).Where("id IN (?)", sellerOrderIDs(database.DB, sellerID))
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+
+	err := preloadSellerItems(query, sellerID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
+// GetSellerOrderByID - detail order untuk seller, hanya dengan line item miliknya
+func GetSellerOrderByID(orderID, sellerID string) (*models.Order, error) {
+	var order models.Order
+
+	err := preloadSellerItems(database.DB, sellerID).
+		Where("id = ? AND id IN (?)", orderID, sellerOrderIDs(database.DB, sellerID)).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&order).Error
+
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	return &order, nil
+}
+
+// ShipSellerOrder - seller mengirim item miliknya. Order pindah ke "shipped"
+// setelah semua item yang tidak dibatalkan sudah dikirim.
+func ShipSellerOrder(actor AuditActor, orderID, courier, trackingNumber string) error {
+	sellerID := actor.UserID
+	courier = strings.TrimSpace(courier)
+	trackingNumber = strings.TrimSpace(trackingNumber)
+
+	if courier == "" || trackingNumber == "" {
+		return errors.New("courier and tracking number are required")
+	}
+
+	orderShipped := false
+	note := courier + " " + trackingNumber
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci order supaya dua seller yang mengirim bersamaan tidak sama-sama
+		// melihat item seller lain belum dikirim (order tertahan di confirmed)
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND id IN (?)", orderID, sellerOrderIDs(tx, sellerID)).First(&order).Error; err != nil {
+			return errors.New("order not found")
+		}
+
+		if order.Status != OrderStatusConfirmed {
+			return errors.New("only confirmed (paid) orders can be shipped")
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND fulfillment_status = ? AND product_id IN (?)", orderID, "pending", sellerProductIDs(tx, sellerID)).
+			Updates(map[string]interface{}{
+				"fulfillment_status": "shipped",
+				"courier":            courier,
+				"tracking_number":    trackingNumber,
+				"shipped_at":         &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("no items left to ship in this order")
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND fulfillment_status = ?", orderID, "pending").
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+
+		if remaining > 0 {
+			return nil
+		}
+
+		orderShipped = true
+		return TransitionOrder(tx, &order, OrderStatusShipped, orderActor(actor, ActorSeller), note)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if orderShipped {
+		publishOrderStatusEvent(orderID, OrderStatusShipped, note)
+	}
+
+	return nil
+}
+
+// CancelSellerOrder - seller membatalkan order yang belum dikirim. Hanya untuk
+// order yang seluruh itemnya milik seller; order campuran dibatalkan admin.
+func CancelSellerOrder(ctx context.Context, actor AuditActor, orderID, reason string) error {
+	sellerID := actor.UserID
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("cancellation reason is required")
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND id IN (?)", orderID, sellerOrderIDs(tx, sellerID)).First(&order).Error; err != nil {
+			return errors.New("order not found")
+		}
+
+		// Pembatalan me-refund seluruh order, jadi tidak boleh menyentuh item seller lain
+		var otherSellers int64
+		if err := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND product_id NOT IN (?)", orderID, sellerProductIDs(tx, sellerID)).
+			Count(&otherSellers).Error; err != nil {
+			return err
+		}
+		if otherSellers > 0 {
+			return errors.New("order contains items from other sellers, please contact an admin to cancel it")
+		}
+
+		var shipped int64
+		if err := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND fulfillment_status = ?", orderID, "shipped").
+			Count(&shipped).Error; err != nil {
+			return err
+		}
+		if shipped > 0 {
+			return errors.New("order has items that were already shipped")
+		}
+
+		return TransitionOrder(tx, &order, OrderStatusCancelled, orderActor(actor, ActorSeller), reason)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sendPendingRefunds(ctx, orderID)
+	publishOrderStatusEvent(orderID, OrderStatusCancelled, reason)
+	return nil
+}
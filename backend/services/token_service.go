@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, please login again")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+// TokenPair - access token (JWT) + refresh token (opaque) untuk client
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // detik sampai access token expired
+
+	// TwoFactorSetupRequired - access token hanya berlaku untuk enroll 2FA (kebijakan role)
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// IssueTokenPair - buat access token dan refresh token baru. familyID kosong berarti login baru.
+func IssueTokenPair(tx *gorm.DB, user *models.User, familyID, userAgent, ipAddress string) (*TokenPair, *models.RefreshToken, error) {
+	// User yang wajib 2FA tapi belum enroll hanya mendapat token setup
+	setupOnly := !user.TwoFactorEnabled && twoFactorRequiredForRole(tx, user.Role)
+	generate := utils.GenerateToken
+	if setupOnly {
+		generate = utils.GenerateTwoFactorSetupToken
+	}
+
+	accessToken, err := generate(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if familyID == "" {
+		familyID = uuid.New().String()
+	}
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	record := models.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(config.AppConfig.RefreshTokenTTL),
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+	}
+
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(config.AppConfig.AccessTokenTTL.Seconds()),
+
+		TwoFactorSetupRequired: setupOnly,
+	}, &record, nil
+}
+
+// RefreshTokens - tukar refresh token dengan pasangan token baru (rotasi).
+// Refresh token yang sudah pernah dipakai dianggap bocor: seluruh family dicabut.
+func RefreshTokens(refreshToken, userAgent, ipAddress string) (*models.User, *TokenPair, error) {
+	if refreshToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	var pair *TokenPair
+	reused := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&current).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if current.RevokedAt != nil {
+			reused = true
+			return revokeTokenFamily(tx, current.FamilyID)
+		}
+
+		if time.Now().After(current.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.Where("id = ?", current.UserID).First(&user).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if !user.IsActive {
+			return errors.New("akun tidak aktif")
+		}
+
+		newPair, next, err := IssueTokenPair(tx, &user, current.FamilyID, userAgent, ipAddress)
+		if err != nil {
+			return err
+		}
+
+		// Cabut token lama secara bersyarat; request paralel dengan token yang sama dianggap reuse
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":  &now,
+				"replaced_by": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		pair = newPair
+		return nil
+	})
+
+	// Pencabutan family harus tetap tersimpan, jadi error reuse dikembalikan setelah commit
+	if err == nil && reused {
+		log.Printf("⚠️  Refresh token reuse detected, token family revoked")
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &user, pair, nil
+}
+
+// Logout - cabut refresh token. allDevices mencabut semua sesi user termasuk access token aktif.
+func Logout(refreshToken string, allDevices bool) error {
+	if refreshToken == "" {
+		return ErrInvalidRefreshToken
+	}
+
+	var current models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&current).Error; err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	if allDevices {
+		return RevokeUserTokens(database.DB, current.UserID)
+	}
+
+	return revokeTokenFamily(database.DB, current.FamilyID)
+}
+
+// RevokeUserTokens - cabut semua refresh token user dan tolak access token yang sudah terbit.
+// Dipanggil saat password / role berubah atau akun dinonaktifkan.
+func RevokeUserTokens(tx *gorm.DB, userID string) error {
+	now := time.Now()
+
+	if err := tx.Model(&models.User{}).Unscoped().
+		Where("id = ?", userID).
+		Update("tokens_revoked_at", &now).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", &now).Error
+}
+
+func revokeTokenFamily(tx *gorm.DB, familyID string) error {
+	now := time.Now()
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", &now).Error
+}
+
+// ValidateAccessToken - validasi JWT lalu cek ke DB bahwa user masih aktif,
+// role belum berubah, dan token tidak terbit sebelum TokensRevokedAt
+func ValidateAccessToken(tokenString string) (*utils.Claims, error) {
+	claims, err := utils.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	if err := CheckSessionActive(claims.UserID, claims.Role, issuedAt); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// CheckSessionActive - cek ulang ke DB bahwa sesi dari access token (user, role,
+// iat) masih berlaku. Dipakai juga oleh koneksi panjang seperti stream SSE.
+func CheckSessionActive(userID, role string, issuedAt time.Time) error {
+	var user models.User
+	if err := database.DB.Select("id", "role", "is_active", "tokens_revoked_at").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		return ErrTokenRevoked
+	}
+
+	if !user.IsActive || user.Role != role {
+		return ErrTokenRevoked
+	}
+
+	// iat berpresisi detik, jadi pembanding juga dibulatkan ke detik
+	if user.TokensRevokedAt != nil && !issuedAt.IsZero() &&
+		issuedAt.Before(user.TokensRevokedAt.Truncate(time.Second)) {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
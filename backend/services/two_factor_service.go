@@ -0,0 +1,517 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sk8consign-backend/config"
+	"sk8consign-backend/database"
+	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
+	"sk8consign-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	twoFactorIssuer = "SK8 Consign"
+
+	// Challenge token login langkah kedua
+	loginChallengeTTL = 5 * time.Minute
+
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("start two-factor enrollment first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+	ErrInvalidLoginChallenge   = errors.New("login challenge is invalid or expired, please login again")
+)
+
+// TwoFactorRequiredError - password benar tapi login harus dilanjutkan dengan kode 2FA
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresIn      int64
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// TwoFactorEnrollment - data untuk authenticator app (secret manual + URI untuk QR code)
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// EnrollTwoFactor - buat secret TOTP baru (belum aktif sampai diverifikasi)
+func EnrollTwoFactor(userID string) (*TwoFactorEnrollment, error) {
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := utils.EncryptString(config.AppConfig.TwoFactorEncryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Model(&user).Update("two_factor_secret", encrypted).Error; err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(twoFactorIssuer, user.Username, secret),
+	}, nil
+}
+
+// VerifyTwoFactorEnrollment - aktifkan 2FA dengan kode pertama dari authenticator app.
+// Mengembalikan recovery codes dalam bentuk plain text (hanya sekali ini).
+func VerifyTwoFactorEnrollment(actor AuditActor, code string) ([]string, error) {
+	userID := actor.UserID
+	var codes []string
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", userID).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		if user.TwoFactorEnabled {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		if user.TwoFactorSecret == "" {
+			return ErrTwoFactorNotEnrolled
+		}
+
+		step, err := verifyUserTOTP(&user, code)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"two_factor_enabled":    true,
+			"two_factor_enabled_at": &now,
+			"two_factor_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditAuthTwoFactorOn, "user", user.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifyTwoFactorChange(userID, "Two-Factor Authentication Enabled",
+		"Two-factor authentication is now active on your account. Keep your recovery codes somewhere safe.")
+
+	return codes, nil
+}
+
+// DisableTwoFactor - matikan 2FA; butuh password dan kode TOTP / recovery code.
+// Ditolak jika role user diwajibkan memakai 2FA.
+func DisableTwoFactor(actor AuditActor, password, code string) error {
+	userID := actor.UserID
+	usedRecoveryCode := false
+	err := database.DB.Transaction(func(tx *gorm.DB) (err error) {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", userID).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		if !user.TwoFactorEnabled {
+			return ErrTwoFactorNotEnabled
+		}
+
+		if twoFactorRequiredForRole(tx, user.Role) {
+			return fmt.Errorf("two-factor authentication is required for %s accounts", user.Role)
+		}
+
+		if !utils.CheckPassword(user.Password, password) {
+			return errors.New("password is incorrect")
+		}
+
+		if usedRecoveryCode, err = verifySecondFactor(tx, &user, code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"two_factor_enabled":    false,
+			"two_factor_secret":     "",
+			"two_factor_last_step":  0,
+			"two_factor_enabled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, actor, AuditAuthTwoFactorOff, "user", user.ID, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if usedRecoveryCode {
+		notifyRecoveryCodeUsed(userID)
+	}
+	notifyTwoFactorChange(userID, "Two-Factor Authentication Disabled",
+		"Two-factor authentication was turned off for your account. If this wasn't you, reset your password immediately.")
+
+	return nil
+}
+
+// RegenerateRecoveryCodes - ganti semua recovery code; butuh kode TOTP yang valid
+func RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	var codes []string
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", userID).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		if !user.TwoFactorEnabled {
+			return ErrTwoFactorNotEnabled
+		}
+
+		step, err := verifyUserTOTP(&user, code)
+		if err != nil {
+			return err
+		}
+		if err := markTOTPStepUsed(tx, user.ID, step); err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// GetRecoveryCodeCount - jumlah recovery code yang belum dipakai
+func GetRecoveryCodeCount(userID string) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// startTwoFactorChallenge - password sudah benar, buat challenge token untuk langkah kedua
+func startTwoFactorChallenge(user *models.User) error {
+	token, err := issueUserToken(database.DB, user.ID, TokenPurposeLoginChallenge, loginChallengeTTL)
+	if err != nil {
+		return err
+	}
+
+	return &TwoFactorRequiredError{
+		ChallengeToken: token,
+		ExpiresIn:      int64(loginChallengeTTL.Seconds()),
+	}
+}
+
+// CompleteTwoFactorLogin - langkah kedua login: challenge token + kode TOTP atau recovery code.
+// Kode salah dihitung sebagai login gagal (back-off & lockout yang sama dengan password).
+func CompleteTwoFactorLogin(challengeToken, code, userAgent, ipAddress string) (*models.User, *TokenPair, error) {
+	ctx := context.Background()
+
+	challenge, err := findUserToken(database.DB, challengeToken, TokenPurposeLoginChallenge)
+	if err != nil {
+		return nil, nil, ErrInvalidLoginChallenge
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ?", challenge.UserID).First(&user).Error; err != nil {
+		return nil, nil, ErrInvalidLoginChallenge
+	}
+
+	if err := checkLoginThrottle(ctx, user.Username, ipAddress); err != nil {
+		return nil, nil, err
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, nil, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil), Locked: true}
+	}
+
+	if !user.IsActive {
+		return nil, nil, ErrAccountInactive
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, nil, ErrInvalidLoginChallenge
+	}
+
+	var tokens *TokenPair
+	usedRecoveryCode := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if usedRecoveryCode, err = verifySecondFactor(tx, &user, code); err != nil {
+			return err
+		}
+
+		if _, err := consumeUserToken(tx, challengeToken, TokenPurposeLoginChallenge); err != nil {
+			return ErrInvalidLoginChallenge
+		}
+
+		tokens, _, err = IssueTokenPair(tx, &user, "", userAgent, ipAddress)
+		return err
+	})
+
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		recordLoginFailure(ctx, user.Username, ipAddress, &user)
+		recordAuditOrLog(AuditActor{IPAddress: ipAddress, UserAgent: userAgent}, AuditAuthLoginFailed, "user", user.ID,
+			map[string]interface{}{"reason": "invalid_2fa_code"})
+		if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+			return nil, nil, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil), Locked: true}
+		}
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resetLoginFailures(ctx, user.Username)
+
+	if usedRecoveryCode {
+		notifyRecoveryCodeUsed(user.ID)
+	}
+
+	recordAuditOrLog(loginAuditActor(&user, userAgent, ipAddress), AuditAuthLogin, "user", user.ID,
+		map[string]interface{}{"method": "2fa"})
+
+	return &user, tokens, nil
+}
+
+// GetTwoFactorPolicies - kebijakan 2FA untuk semua role (default tidak wajib)
+func GetTwoFactorPolicies() ([]models.TwoFactorPolicy, error) {
+	var stored []models.TwoFactorPolicy
+	if err := database.DB.Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	byRole := make(map[string]models.TwoFactorPolicy, len(stored))
+	for _, policy := range stored {
+		byRole[policy.Role] = policy
+	}
+
+	policies := make([]models.TwoFactorPolicy, 0, len(rbac.Roles()))
+	for _, role := range rbac.Roles() {
+		policy, ok := byRole[role]
+		if !ok {
+			policy = models.TwoFactorPolicy{Role: role}
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// SetTwoFactorPolicy - admin mewajibkan / membebaskan 2FA untuk satu role.
+// User role tersebut yang belum enroll hanya bisa enroll 2FA setelah login berikutnya.
+func SetTwoFactorPolicy(actor AuditActor, role string, required bool) (*models.TwoFactorPolicy, error) {
+	if !containsString(rbac.Roles(), role) {
+		return nil, fmt.Errorf("invalid role, must be one of: %s", strings.Join(rbac.Roles(), ", "))
+	}
+
+	adminID := actor.UserID
+	policy := models.TwoFactorPolicy{
+		Role:      role,
+		Required:  required,
+		UpdatedBy: &adminID,
+		UpdatedAt: time.Now(),
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		previous := twoFactorRequiredForRole(tx, role)
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "role"}},
+			DoUpdates: clause.AssignmentColumns([]string{"required", "updated_by", "updated_at"}),
+		}).Create(&policy).Error; err != nil {
+			return err
+		}
+
+		return RecordAuditChange(tx, actor, AuditAuthTwoFactorRule, "two_factor_policy", role,
+			map[string]interface{}{"required": previous},
+			map[string]interface{}{"required": required},
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🔐 2FA policy for %s set to required=%t by %s", role, required, adminID)
+
+	return &policy, nil
+}
+
+// twoFactorRequiredForRole - apakah kebijakan mewajibkan 2FA untuk role ini
+func twoFactorRequiredForRole(tx *gorm.DB, role string) bool {
+	var policy models.TwoFactorPolicy
+	if err := tx.Where("role = ?", role).First(&policy).Error; err != nil {
+		return false
+	}
+	return policy.Required
+}
+
+// verifySecondFactor - terima kode TOTP (6 digit) atau recovery code (xxxx-xxxx).
+// usedRecoveryCode true jika recovery code terpakai; pemanggil memberi tahu user
+// lewat notifyRecoveryCodeUsed setelah transaksi commit.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code string) (usedRecoveryCode bool, err error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, ErrInvalidTwoFactorCode
+	}
+
+	if strings.Contains(code, "-") || len(code) > utils.TOTPDigits {
+		if err := useRecoveryCode(tx, user, code); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	step, err := verifyUserTOTP(user, code)
+	if err != nil {
+		return false, err
+	}
+
+	return false, markTOTPStepUsed(tx, user.ID, step)
+}
+
+// verifyUserTOTP - cocokkan kode dengan secret user, mengembalikan time step yang cocok
+func verifyUserTOTP(user *models.User, code string) (int64, error) {
+	secret, err := utils.DecryptString(config.AppConfig.TwoFactorEncryptionKey, user.TwoFactorSecret)
+	if err != nil {
+		log.Printf("❌ Failed to decrypt 2FA secret for %s: %v", user.Username, err)
+		return 0, errors.New("failed to verify authentication code")
+	}
+
+	step, ok := utils.VerifyTOTP(secret, code, time.Now())
+	if !ok {
+		return 0, ErrInvalidTwoFactorCode
+	}
+
+	return step, nil
+}
+
+// markTOTPStepUsed - kode TOTP hanya berlaku sekali; step yang sama / lebih lama ditolak
+func markTOTPStepUsed(tx *gorm.DB, userID string, step int64) error {
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND two_factor_last_step < ?", userID, step).
+		Update("two_factor_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// useRecoveryCode - tandai recovery code terpakai secara atomik
+func useRecoveryCode(tx *gorm.DB, user *models.User, code string) error {
+	normalized := normalizeRecoveryCode(code)
+
+	now := time.Now()
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalized)).
+		Update("used_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	log.Printf("🔑 Recovery code used by %s", user.Username)
+	return nil
+}
+
+// notifyRecoveryCodeUsed - beri tahu user sisa recovery code; dipanggil setelah commit
+// supaya notifikasi tidak terkirim untuk perubahan yang di-rollback
+func notifyRecoveryCodeUsed(userID string) {
+	var remaining int64
+	database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining)
+
+	notifyTwoFactorChange(userID, "Recovery Code Used",
+		fmt.Sprintf("A recovery code was used to sign in to your account. You have %d recovery codes left.", remaining))
+}
+
+// replaceRecoveryCodes - hapus recovery code lama dan buat yang baru
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		raw := hex.EncodeToString(b)
+		code := raw[:4] + "-" + raw[4:]
+
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			ID:       uuid.New().String(),
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(strings.ReplaceAll(code, "-", ""), " ", "")
+}
+
+func notifyTwoFactorChange(userID, title, message string) {
+	if _, err := CreateNotification(userID, title, message, "security"); err != nil {
+		log.Printf("❌ Failed to create security notification for %s: %v", userID, err)
+	}
+}
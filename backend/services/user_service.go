@@ -2,9 +2,15 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"sk8consign-backend/database"
 	"sk8consign-backend/models"
+	"sk8consign-backend/rbac"
 	"sk8consign-backend/utils"
+	"strings"
+
+	"gorm.io/gorm"
 )
 
 // GetUserProfile - get user profile by ID
@@ -19,7 +25,8 @@ func GetUserProfile(userID string) (*models.User, error) {
 }
 
 // UpdateUserProfile - update user profile
-func UpdateUserProfile(userID string, fullName string, phone string, email string) (*models.User, error) {
+func UpdateUserProfile(actor AuditActor, fullName string, phone string, email string) (*models.User, error) {
+	userID := actor.UserID
 	var user models.User
 
 	// Check if user exists
@@ -42,18 +49,48 @@ func UpdateUserProfile(userID string, fullName string, phone string, email strin
 		"email":     email,
 	}
 
-	if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+	// Email baru harus diverifikasi ulang
+	emailChanged := email != user.Email
+	if emailChanged {
+		updates["email_verified_at"] = nil
+	}
+
+	before := map[string]interface{}{
+		"full_name": user.FullName,
+		"phone":     user.Phone,
+		"email":     user.Email,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return RecordAuditChange(tx, actor, AuditUserUpdate, "user", user.ID, before, map[string]interface{}{
+			"full_name": fullName,
+			"phone":     phone,
+			"email":     email,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Reload user
 	database.DB.First(&user, "id = ?", userID)
 
+	if emailChanged {
+		if err := SendVerificationEmail(&user); err != nil {
+			log.Printf("⚠️  Verification email for %s not sent: %v", user.Username, err)
+		}
+	}
+
 	return &user, nil
 }
 
 // ChangePassword - change user password
-func ChangePassword(userID string, oldPassword string, newPassword string) error {
+func ChangePassword(actor AuditActor, oldPassword string, newPassword string) error {
+	userID := actor.UserID
 	var user models.User
 
 	// Get user
@@ -72,30 +109,65 @@ func ChangePassword(userID string, oldPassword string, newPassword string) error
 		return errors.New("failed to hash password")
 	}
 
-	// Update password
-	if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
-		return err
-	}
-
-	return nil
+	// Update password dan cabut semua sesi lama
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		if err := RevokeUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, AuditAuthPasswordChange, "user", user.ID, nil)
+	})
 }
 
-// DeactivateAccount - deactivate user account (soft delete)
-func DeactivateAccount(userID string) error {
+// AssignRole - admin mengganti role user. Semua sesi user dicabut supaya
+// permission baru langsung berlaku.
+func AssignRole(actor AuditActor, userID, role string) (*models.User, error) {
+	if !rbac.IsValidRole(role) {
+		return nil, fmt.Errorf("invalid role, must be one of: %s", strings.Join(rbac.Roles(), ", "))
+	}
+
 	var user models.User
+	changed := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := loadManageableUser(tx, actor, userID, &user); err != nil {
+			return err
+		}
 
-	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return errors.New("user not found")
+		if user.Role == role {
+			return nil
+		}
+
+		previousRole := user.Role
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		if err := RevokeUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+
+		changed = true
+		return RecordAudit(tx, actor, AuditUserRoleChange, "user", user.ID, map[string]interface{}{
+			"from": previousRole,
+			"to":   role,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Set is_active to false dan soft delete
-	if err := database.DB.Model(&user).Update("is_active", false).Error; err != nil {
-		return err
+	if !changed {
+		return &user, nil
 	}
 
-	if err := database.DB.Delete(&user).Error; err != nil {
-		return err
+	log.Printf("👤 Role of %s set to %s by %s", user.Username, role, actor.UserID)
+
+	if _, err := CreateNotification(user.ID, "Account Role Changed",
+		fmt.Sprintf("Your account role is now %s. Please sign in again.", role), "security"); err != nil {
+		log.Printf("❌ Failed to notify %s about role change: %v", user.Username, err)
 	}
 
-	return nil
+	return &user, nil
 }
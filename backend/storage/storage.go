@@ -0,0 +1,35 @@
+// Package storage - penyimpanan file (gambar produk, dll) di balik interface BlobStore.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidKey - key kosong, absolut, atau mengandung ".."
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore - kontrak penyimpanan blob. Key memakai "/" sebagai pemisah,
+// mis. "products/<product-id>/<uuid>.jpg".
+type BlobStore interface {
+	Name() string
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL - URL publik untuk key (tidak mengecek apakah blob ada)
+	URL(key string) string
+}
+
+// validKey - tolak key yang bisa keluar dari root penyimpanan
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"sk8consign-backend/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims - JWT claims structure
@@ -12,35 +18,93 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+
+	// TwoFactorSetup - token terbatas untuk user yang wajib 2FA tapi belum enroll;
+	// hanya endpoint enroll / verify 2FA yang menerimanya
+	TwoFactorSetup bool `json:"tfa_setup,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
-// GenerateToken - generate JWT token
+// JWTIssuer - nilai claim iss untuk token SK8
+const JWTIssuer = "sk8consign"
+
+var keyRing *KeyRing
+
+// SetKeyRing - pasang key ring untuk tanda tangan & verifikasi JWT
+func SetKeyRing(ring *KeyRing) {
+	keyRing = ring
+}
+
+// GetKeyRing - key ring aktif (nil jika belum di-setup)
+func GetKeyRing() *KeyRing {
+	return keyRing
+}
+
+// GenerateToken - generate JWT access token (berumur pendek, lihat AccessTokenTTL)
+// ditandatangani kunci aktif dengan header kid
 func GenerateToken(userID, username, role string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expired 24 jam
+	return generateToken(userID, username, role, false)
+}
+
+// GenerateTwoFactorSetupToken - access token yang hanya boleh dipakai untuk enroll 2FA
+func GenerateTwoFactorSetupToken(userID, username, role string) (string, error) {
+	return generateToken(userID, username, role, true)
+}
+
+func generateToken(userID, username, role string, twoFactorSetup bool) (string, error) {
+	if keyRing == nil {
+		return "", errors.New("JWT key ring is not configured")
+	}
+
+	expirationTime := time.Now().Add(config.AppConfig.AccessTokenTTL)
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:         userID,
+		Username:       username,
+		Role:           role,
+		TwoFactorSetup: twoFactorSetup,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "sk8consign",
+			Issuer:    JWTIssuer,
+			ID:        uuid.New().String(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+	key := keyRing.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
 }
 
-// ValidateToken - validate JWT token
+// ValidateToken - validate JWT token: kid harus dikenal key ring dan alg harus
+// sesuai jenis kuncinya (token HS256 / "none" selalu ditolak)
 func ValidateToken(tokenString string) (*Claims, error) {
+	if keyRing == nil {
+		return nil, errors.New("JWT key ring is not configured")
+	}
+
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		key, err := keyRing.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+
+		return key.Public, nil
+	},
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithIssuer(JWTIssuer),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, err
@@ -52,3 +116,18 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// GenerateOpaqueToken - token acak opaque (refresh token, link email); yang disimpan di DB hanya hash-nya
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken - SHA-256 (hex) dari token opaque
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
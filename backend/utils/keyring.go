@@ -0,0 +1,335 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritma tanda tangan JWT yang didukung
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey - satu kunci di key ring. Private nil berarti kunci hanya untuk verifikasi
+// (kunci lama yang sudah dipensiunkan tapi token-nya mungkin masih berlaku).
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+}
+
+// KeyRing - kumpulan kunci JWT yang dimuat dari direktori.
+//
+// Setiap file <kid>.pem berisi private key PKCS#8 (RSA atau Ed25519); file
+// <kid>.pub.pem berisi public key PKIX untuk verifikasi saja. Kunci aktif
+// adalah activeKID jika diisi, selain itu private key terbaru (mod time).
+// Rotasi: tambahkan file kunci baru lalu Reload (SIGHUP); hapus kunci lama
+// setelah semua token yang ditandatanganinya expired.
+type KeyRing struct {
+	dir       string
+	algorithm string
+	activeKID string
+
+	mu     sync.RWMutex
+	keys   map[string]*SigningKey
+	active *SigningKey
+}
+
+// NewKeyRing - muat key ring dari dir; jika belum ada private key sama sekali,
+// kunci baru dengan algorithm dibuat dan disimpan ke dir
+func NewKeyRing(dir, algorithm, activeKID string) (*KeyRing, error) {
+	if algorithm != AlgRS256 && algorithm != AlgEdDSA {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+
+	ring := &KeyRing{dir: dir, algorithm: algorithm, activeKID: activeKID}
+	if err := ring.Reload(); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Reload - baca ulang semua kunci dari disk. Jika gagal, kunci lama tetap dipakai.
+func (k *KeyRing) Reload() error {
+	keys, err := loadKeys(k.dir)
+	if err != nil {
+		return err
+	}
+
+	if !hasPrivateKey(keys) {
+		key, err := generateKey(k.dir, k.algorithm)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+	}
+
+	active, err := pickActiveKey(keys, k.activeKID)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.mu.Unlock()
+
+	return nil
+}
+
+// Active - kunci untuk menandatangani token baru
+func (k *KeyRing) Active() *SigningKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// Lookup - kunci verifikasi berdasarkan kid
+func (k *KeyRing) Lookup(kid string) (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// JWK - public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet - isi /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS - semua public key di ring, kunci aktif lebih dulu
+func (k *KeyRing) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range sortedKeys(k.keys) {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		if key == k.active {
+			set.Keys = append([]JWK{jwk}, set.Keys...)
+		} else {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	return set
+}
+
+func loadKeys(dir string) (map[string]*SigningKey, error) {
+	keys := make(map[string]*SigningKey)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		publicOnly := strings.HasSuffix(name, ".pub.pem")
+		kid := strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")
+
+		key, err := parseKey(kid, data, publicOnly)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		key.CreatedAt = info.ModTime()
+
+		// Private key menang atas public key dengan kid yang sama
+		if existing, ok := keys[kid]; ok && existing.Private != nil {
+			continue
+		}
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseKey(kid string, data []byte, publicOnly bool) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &SigningKey{ID: kid}
+
+	if publicOnly {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = pub
+	} else {
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	return key, nil
+}
+
+func generateKey(dir, algorithm string) (*SigningKey, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	kid := time.Now().Format("20060102") + "-" + hex.EncodeToString(suffix)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	return parseKey(kid, data, false)
+}
+
+func hasPrivateKey(keys map[string]*SigningKey) bool {
+	for _, key := range keys {
+		if key.Private != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func pickActiveKey(keys map[string]*SigningKey, activeKID string) (*SigningKey, error) {
+	if activeKID != "" {
+		key, ok := keys[activeKID]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("active JWT key %q not found", activeKID)
+		}
+		return key, nil
+	}
+
+	for _, key := range sortedKeys(keys) {
+		if key.Private != nil {
+			return key, nil
+		}
+	}
+
+	return nil, errors.New("no JWT signing key available")
+}
+
+// sortedKeys - kunci terbaru lebih dulu
+func sortedKeys(keys map[string]*SigningKey) []*SigningKey {
+	sorted := make([]*SigningKey, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, key)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID > sorted[j].ID
+	})
+
+	return sorted
+}